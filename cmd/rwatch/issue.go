@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xaelophone/ralph-setup/internal/config"
+	"github.com/xaelophone/ralph-setup/internal/issues"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+)
+
+var (
+	syncForce  bool
+	postUpdate bool
+	postYes    bool
+)
+
+// progressMarker identifies progress comments so `post --update` can edit them
+const progressMarker = "<!-- ralph-progress -->"
+
+// newIssueCmd creates the `rwatch issue` command group used by ralph-gh
+func newIssueCmd() *cobra.Command {
+	issueCmd := &cobra.Command{
+		Use:   "issue",
+		Short: "Bridge between the linked issue (GitHub, GitLab, Gitea) and PRD.md",
+		Long: `Bridge between the linked issue and PRD.md + progress.txt.
+
+The forge is detected from the origin remote (github.com, *gitlab*, *gitea*,
+codeberg.org) or set in .ralph-config.json:
+  {"issue_tracker": {"type": "gitlab", "url": "https://git.example.com/api/v4"}}
+
+Authentication:
+  GitHub: GITHUB_TOKEN, GH_TOKEN or 'gh auth token'
+  GitLab: GITLAB_TOKEN
+  Gitea:  GITEA_TOKEN
+  (or the variable named by "token_env" in issue_tracker)`,
+	}
+
+	linkCmd := &cobra.Command{
+		Use:   "link <issue>",
+		Short: "Link this project to an issue",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runIssueLink,
+	}
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Create PRD.md tasks from the linked issue's sub-issues and task list",
+		Long: `Fetch the linked issue and write PRD.md.
+
+Each sub-issue, and each "- [ ]" item in the issue body, becomes a 🤖 task
+ending in (#N). When the orchestrator completes such a task it comments with
+the commit link and closes the sub-issue (or ticks the item in the issue body).
+
+GitLab has no sub-issues in its REST API, so linked issues are used; Gitea
+uses the issue's dependencies.`,
+		RunE: runIssueSync,
+	}
+	syncCmd.Flags().BoolVarP(&syncForce, "force", "f", false, "Overwrite an existing PRD.md without asking")
+
+	postCmd := &cobra.Command{
+		Use:   "post",
+		Short: "Post a progress summary as an issue comment",
+		RunE:  runIssuePost,
+	}
+	postCmd.Flags().BoolVarP(&postUpdate, "update", "u", false, "Update the previous progress comment instead of posting a new one")
+	postCmd.Flags().BoolVarP(&postYes, "yes", "y", false, "Post without asking for confirmation")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the current link and progress",
+		RunE:  runIssueStatus,
+	}
+
+	issueCmd.AddCommand(linkCmd, syncCmd, postCmd, statusCmd)
+	return issueCmd
+}
+
+// openTracker loads the linked issue and creates the tracker for its forge
+func openTracker() (*issues.Link, issues.Tracker, error) {
+	link, err := issues.LoadLink()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("no issue linked. Run: ralph-gh link <issue-number>")
+		}
+		return nil, nil, err
+	}
+
+	tracker, err := issues.NewTracker(link, config.LoadProjectConfig().IssueTracker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return link, tracker, nil
+}
+
+func runIssueLink(cmd *cobra.Command, args []string) error {
+	number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return fmt.Errorf("invalid issue number: %s", args[0])
+	}
+
+	link, err := issues.LinkFromGit()
+	if err != nil {
+		return err
+	}
+	link.Issue = number
+
+	tracker, err := issues.NewTracker(link, config.LoadProjectConfig().IssueTracker)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🔗 Linking to issue #%d...\n", number)
+
+	issue, err := tracker.GetIssue(number)
+	if err != nil {
+		return fmt.Errorf("issue #%d not found in %s: %w", number, link.Repo, err)
+	}
+
+	if err := issues.SaveLink(number); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Linked to: #%d - %s (%s)\n", number, issue.Title, tracker.Name())
+	fmt.Println()
+	fmt.Println("Next steps:")
+	fmt.Println("  ralph-gh sync    # Fetch issue and create PRD.md")
+	fmt.Println("  ralph-gh post    # Post progress as issue comment")
+
+	return nil
+}
+
+func runIssueSync(cmd *cobra.Command, args []string) error {
+	link, tracker, err := openTracker()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📥 Fetching issue #%d from %s (%s)...\n", link.Issue, link.Repo, tracker.Name())
+
+	issue, err := tracker.GetIssue(link.Issue)
+	if err != nil {
+		return err
+	}
+
+	subIssues, err := tracker.SubIssues(link.Issue)
+	if err != nil {
+		// Sub-issues may be unavailable (older servers); fall back to the task list
+		fmt.Printf("⚠️  Could not fetch sub-issues: %v\n", err)
+		subIssues = nil
+	}
+
+	tasks := issues.TasksFromIssue(issue, subIssues)
+
+	if _, err := os.Stat("PRD.md"); err == nil && !syncForce {
+		fmt.Println()
+		fmt.Println("⚠️  PRD.md already exists.")
+		if !confirm("Overwrite with issue content?") {
+			return nil
+		}
+	}
+
+	content := issues.RenderPRD(issue, tracker.IssueURL(link.Issue), tasks)
+	if err := os.WriteFile("PRD.md", []byte(content), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Created PRD.md from issue #%d (%d tasks)\n", link.Issue, len(tasks))
+	fmt.Println()
+	fmt.Println("Next steps:")
+	if len(tasks) == 0 {
+		fmt.Println("  1. Edit PRD.md to break the issue into atomic 🤖/🧑 tasks")
+	} else {
+		fmt.Println("  1. Review PRD.md and mark human-only tasks with 🧑")
+	}
+	fmt.Println("  2. Run 'rwatch' or 'ralph-loop' to start implementing")
+	fmt.Println("  3. Completed (#N) tasks are closed on the issue tracker automatically by rwatch")
+
+	return nil
+}
+
+func runIssuePost(cmd *cobra.Command, args []string) error {
+	link, tracker, err := openTracker()
+	if err != nil {
+		return err
+	}
+
+	progress, err := os.ReadFile("progress.txt")
+	if err != nil || len(strings.TrimSpace(string(progress))) == 0 {
+		return fmt.Errorf("no progress.txt found or it's empty. Complete some tasks first")
+	}
+
+	comment := buildProgressComment(string(progress))
+
+	fmt.Printf("📤 Posting progress to issue #%d...\n", link.Issue)
+	fmt.Println()
+	fmt.Println("Preview:")
+	fmt.Println("─────────────────────────────────────────")
+	fmt.Println(headLines(comment, 30))
+	fmt.Println("...")
+	fmt.Println("─────────────────────────────────────────")
+	fmt.Println()
+
+	if !postYes && !confirm("Post this comment?") {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	if postUpdate {
+		err = tracker.UpsertComment(link.Issue, progressMarker, comment)
+	} else {
+		err = tracker.Comment(link.Issue, comment)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("✅ Posted progress to issue #%d\n", link.Issue)
+	fmt.Printf("   %s\n", tracker.IssueURL(link.Issue))
+	return nil
+}
+
+// buildProgressComment renders the progress summary posted to the issue
+func buildProgressComment(progress string) string {
+	tasks, _ := parser.ParsePRD("PRD.md")
+	completed := parser.CountCompleted(tasks)
+
+	var sb strings.Builder
+	sb.WriteString(progressMarker + "\n")
+	sb.WriteString("## Progress Update\n\n")
+	sb.WriteString(fmt.Sprintf("**Status:** %d/%d tasks complete (%d remaining)\n\n",
+		completed, len(tasks), len(tasks)-completed))
+
+	sb.WriteString("### Recent Progress\n\n```\n")
+	sb.WriteString(tailLines(strings.TrimRight(progress, "\n"), 50))
+	sb.WriteString("\n```\n\n")
+
+	sb.WriteString("### PRD Status\n\n")
+	if len(tasks) == 0 {
+		sb.WriteString("_No PRD.md found_\n")
+	} else {
+		sb.WriteString("```markdown\n")
+		for i, t := range tasks {
+			if i == 20 {
+				break
+			}
+			box := "[ ]"
+			if t.Complete {
+				box = "[x]"
+			}
+			sb.WriteString("- " + box + " " + t.Title + "\n")
+		}
+		sb.WriteString("```\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\n---\n_Posted by [ralph-gh](https://github.com/xaelophone/ralph-setup) at %s_\n",
+		time.Now().Format("2006-01-02 15:04")))
+	return sb.String()
+}
+
+func runIssueStatus(cmd *cobra.Command, args []string) error {
+	link, tracker, err := openTracker()
+	if err != nil {
+		fmt.Println("📎 Not linked to any issue")
+		fmt.Println()
+		fmt.Println("Link with: ralph-gh link <issue-number>")
+	} else {
+		title := "(unable to fetch)"
+		if issue, err := tracker.GetIssue(link.Issue); err == nil {
+			title = issue.Title
+		}
+		fmt.Printf("📎 Linked to: #%d - %s (%s)\n", link.Issue, title, tracker.Name())
+		fmt.Printf("   %s\n", tracker.IssueURL(link.Issue))
+	}
+
+	fmt.Println()
+	fmt.Println("─────────────────────────────────────────")
+	fmt.Println()
+
+	if tasks, err := parser.ParsePRD("PRD.md"); err == nil {
+		aiRemaining, humanRemaining := 0, 0
+		for _, t := range tasks {
+			if t.Complete {
+				continue
+			}
+			if strings.Contains(t.Title, "🤖") {
+				aiRemaining++
+			} else if strings.Contains(t.Title, "🧑") {
+				humanRemaining++
+			}
+		}
+		fmt.Printf("📋 PRD.md: %d/%d tasks complete\n", parser.CountCompleted(tasks), len(tasks))
+		fmt.Printf("   Remaining: %d 🤖 AI tasks, %d 🧑 human tasks\n", aiRemaining, humanRemaining)
+	} else {
+		fmt.Println("📋 PRD.md: Not found")
+	}
+
+	fmt.Println()
+	if entries, err := parser.ParseProgress("progress.txt"); err == nil && len(entries) > 0 {
+		last := entries[len(entries)-1]
+		fmt.Printf("📝 progress.txt: %d entries\n", len(entries))
+		fmt.Printf("   Last: [%s] %s\n", last.Timestamp, last.Title)
+	} else {
+		fmt.Println("📝 progress.txt: Empty or not found")
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Print(question + " (y/N) ")
+	response, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(response), "y")
+}
+
+func headLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tailLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
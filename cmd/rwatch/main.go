@@ -3,13 +3,20 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"github.com/xaelophone/ralph-setup/internal/cli"
 	"github.com/xaelophone/ralph-setup/internal/config"
+	"github.com/xaelophone/ralph-setup/internal/metrics"
 	"github.com/xaelophone/ralph-setup/internal/model"
+	"github.com/xaelophone/ralph-setup/internal/notify"
 	"github.com/xaelophone/ralph-setup/internal/orchestrator"
+	"github.com/xaelophone/ralph-setup/internal/prd"
 	"github.com/xaelophone/ralph-setup/internal/runner"
+	"github.com/xaelophone/ralph-setup/internal/tracing"
 )
 
 var (
@@ -19,6 +26,13 @@ var (
 	maxIterations int
 	cliBackend    string
 	cliModel      string
+	maxBudget     float64
+	maxAttempts   int
+	metricsAddr   string
+	traceTarget   string
+	detachKey     string
+	autoContinue  bool
+	selection     prd.Selection
 )
 
 func main() {
@@ -56,34 +70,90 @@ Usage:
   rwatch                        # Claude (default)
   rwatch --cli codex            # OpenAI Codex
   rwatch --cli claude --model claude-sonnet-4-20250514
-  rwatch --legacy               # Legacy PTY mode
+  rwatch --legacy               # Legacy PTY mode ([a] attaches keys to Claude)
+  rwatch --legacy --auto-continue  # ...moving on to the next task on <promise>COMPLETE</promise>
   rwatch --monitor-only         # Just watch files
 
 Configuration (precedence: flags > env > .ralph-config.json > defaults):
   Flags:       --cli, --model
   Environment: RALPH_CLI, RALPH_MODEL
-  File:        .ralph-config.json {"cli": "codex", "model": "gpt-4o"}`,
+  File:        .ralph-config.json {"cli": "codex", "model": "gpt-4o"}
+
+Webhook notifications (.ralph-config.json):
+  {"notifications": {"webhooks": [{"url": "https://ntfy.sh/my-ralph", "format": "ntfy",
+    "events": ["task_blocked", "session_finished", "session_failed"]}]}}
+
+Terminal alerts (.ralph-config.json; bell, osc9, osc777, tmux):
+  {"tui_notifications": {"blocked": ["bell", "osc9"], "stopped": ["bell"], "error": ["tmux"]}}
+
+Task priority (P0-P3, or !! for P0) and #tags in PRD.md scope a run:
+  rwatch --only-tags backend --priority P1  # Only P0/P1 tasks tagged #backend
+  rwatch --skip-tags frontend
+
+Several PRD files, one epic each (.ralph-config.json; epic_order: sequential or priority):
+  {"prd": ["prd/*.md"], "epic_order": "sequential"}
+
+Metrics (Prometheus text format at /metrics):
+  rwatch --metrics-addr :9464
+
+Tracing (OTLP/JSON; session → iteration → tool call spans):
+  rwatch --trace ralph-trace.json           # Write to a file
+  rwatch --trace http://localhost:4318      # Send to an OTLP HTTP collector (e.g. Jaeger)
+
+End-of-session pull request (.ralph-config.json):
+  {"pull_request": {"enabled": true, "base": "main", "draft": true, "labels": ["ralph"]}}`,
 		Version: version,
+		Args:    cliArgs,
 		RunE:    runRwatch,
 	}
 
 	rootCmd.Flags().BoolVar(&monitorOnly, "monitor-only", false, "Only monitor files, don't run AI")
 	rootCmd.Flags().BoolVar(&legacyMode, "legacy", false, "Use legacy PTY mode instead of orchestrator")
 	rootCmd.Flags().IntVar(&maxIterations, "max-iterations", 100, "Maximum iterations in orchestrator mode")
+	rootCmd.Flags().Float64Var(&maxBudget, "max-budget", 0, "Stop after spending this many USD (overrides max_budget_usd)")
+	rootCmd.Flags().IntVar(&maxAttempts, "max-task-attempts", 0, "Failed attempts before a task is marked ⛔ blocked (default 3, -1 = unlimited)")
+	rootCmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9464)")
+	rootCmd.Flags().StringVar(&traceTarget, "trace", "", "Export an OTLP/JSON trace to a file or OTLP HTTP endpoint (overrides trace)")
+	rootCmd.Flags().BoolVar(&autoContinue, "auto-continue", false, "In --legacy, send /clear and the next task's prompt when the CLI outputs the completion token")
+	rootCmd.Flags().StringVar(&detachKey, "detach-key", "", "Key that leaves attach mode in --legacy, e.g. ctrl+b (default ctrl+], overrides detach_key)")
+	rootCmd.Flags().StringSliceVar(&selection.OnlyTags, "only-tags", nil, "Only run tasks with one of these tags (e.g. backend,api)")
+	rootCmd.Flags().StringSliceVar(&selection.SkipTags, "skip-tags", nil, "Never run tasks with one of these tags")
+	rootCmd.Flags().StringVar(&selection.Priority, "priority", "", "Only run tasks of this priority or higher (P0-P3)")
 	rootCmd.Flags().StringVarP(&cliBackend, "cli", "c", "", "CLI backend: claude (default) or codex")
 	rootCmd.Flags().StringVarP(&cliModel, "model", "m", "", "Model to use (e.g., claude-sonnet-4-20250514, gpt-4o)")
 
+	rootCmd.AddCommand(newIssueCmd())
+	rootCmd.AddCommand(newPromptCmd())
+	rootCmd.AddCommand(newPRDCmd())
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
+// cliArgs accepts arguments for the CLI only after --, so a mistyped
+// subcommand is reported instead of being passed to the CLI
+func cliArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 || cmd.ArgsLenAtDash() == 0 {
+		return nil
+	}
+	if cmd.SuggestionsMinimumDistance <= 0 {
+		cmd.SuggestionsMinimumDistance = 2
+	}
+	msg := fmt.Sprintf("unknown command %q for %q", args[0], cmd.CommandPath())
+	if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+		msg += "\n\nDid you mean this?\n\t" + strings.Join(suggestions, "\n\t") + "\n"
+	}
+	return fmt.Errorf("%s\nRun '%s --help' for usage", msg, cmd.CommandPath())
+}
+
 func runRwatch(cmd *cobra.Command, args []string) error {
 	// Get extra CLI args (everything after --)
 	extraArgs := args
 
 	// Load CLI configuration (flags > env > file > defaults)
 	cliConfig := config.LoadCLIConfig(cliBackend, cliModel)
+	projectConfig := config.LoadProjectConfig()
 
 	// Validate CLI backend
 	if !cliConfig.Backend.IsValid() {
@@ -97,14 +167,93 @@ func runRwatch(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
+	// Webhook notifications for session events
+	notifier, err := notify.New(projectConfig.Notifications)
+	if err != nil {
+		return fmt.Errorf("invalid notifications config: %w", err)
+	}
+	defer notifier.Close(10 * time.Second)
+
+	budget := projectConfig.MaxBudgetUSD
+	if maxBudget > 0 {
+		budget = maxBudget
+	}
+
+	attempts := orchestrator.DefaultConfig().MaxTaskAttempts
+	if projectConfig.MaxAttempts != 0 {
+		attempts = projectConfig.MaxAttempts
+	}
+	if maxAttempts != 0 {
+		attempts = maxAttempts
+	}
+
+	project, err := prdProject(projectConfig)
+	if err != nil {
+		return err
+	}
+	if err := projectConfig.TUIAlerts.Validate(); err != nil {
+		return err
+	}
+	if err := selection.Validate(); err != nil {
+		return err
+	}
+
+	if detachKey == "" {
+		detachKey = projectConfig.DetachKey
+	}
+	if detachKey != "" {
+		if detachKey, err = model.ParseDetachKey(detachKey); err != nil {
+			return err
+		}
+	}
+	autoContinue = autoContinue || projectConfig.AutoContinue
+
+	// Prompts for continuing a legacy session, rendered as the orchestrator would
+	promptConfig := orchestrator.DefaultConfig()
+	promptConfig.CLIConfig = cliConfig
+	promptConfig.PRD = project
+	promptConfig.Selection = selection
+	nextPrompt := func(iteration int) (string, bool, error) {
+		return orchestrator.NextPrompt(promptConfig, iteration)
+	}
+
 	// Determine mode: orchestrator (default), legacy PTY, or monitor-only
 	useOrchestrator := !legacyMode && !monitorOnly
 
+	// Prometheus metrics for orchestrator mode
+	var collector *metrics.Collector
+	if metricsAddr != "" && useOrchestrator {
+		collector = metrics.NewCollector(cliConfig.Backend.String())
+		srv, err := metrics.Serve(metricsAddr, collector)
+		if err != nil {
+			return fmt.Errorf("failed to start metrics endpoint: %w", err)
+		}
+		defer srv.Close()
+	}
+
+	// OTLP trace export for orchestrator mode
+	if traceTarget == "" {
+		traceTarget = projectConfig.Trace
+	}
+	var tracer *tracing.Tracer
+	if traceTarget != "" && useOrchestrator {
+		tracer, err = tracing.New(traceTarget, cliConfig.Backend.String(), version)
+		if err != nil {
+			return err
+		}
+		defer tracer.Close(10 * time.Second)
+	}
+
 	// Create the model with all options
 	m := model.New(model.Options{
 		MonitorOnly:      monitorOnly,
 		OrchestratorMode: useOrchestrator,
 		ClaudeArgs:       extraArgs,
+		Alerts:           projectConfig.TUIAlerts,
+		PRD:              project,
+		DetachKey:        detachKey,
+		AutoContinue:     autoContinue,
+		NextPrompt:       nextPrompt,
 	})
 
 	// Create the Bubbletea program
@@ -121,6 +270,15 @@ func runRwatch(cmd *cobra.Command, args []string) error {
 			fmt.Printf("   Model: %s\n", cliConfig.Model)
 		}
 		fmt.Println("   Real-time completion detection enabled")
+		if s := selection.String(); s != "" {
+			fmt.Printf("   Tasks: %s\n", s)
+		}
+		if collector != nil {
+			fmt.Printf("   Metrics: http://%s/metrics\n", metricsAddr)
+		}
+		if tracer != nil {
+			fmt.Printf("   Trace: %s\n", traceTarget)
+		}
 		fmt.Println()
 
 		go func() {
@@ -128,8 +286,25 @@ func runRwatch(cmd *cobra.Command, args []string) error {
 			orchConfig.MaxIterations = maxIterations
 			orchConfig.CLIConfig = cliConfig
 			orchConfig.CLIConfig.ExtraArgs = extraArgs
+			orchConfig.PullRequest = projectConfig.PullRequest
+			orchConfig.IssueTracker = projectConfig.IssueTracker
+			orchConfig.MaxBudgetUSD = budget
+			orchConfig.MaxTaskAttempts = max(0, attempts)
+			orchConfig.PRD = project
+			orchConfig.Selection = selection
 
 			orch := orchestrator.New(orchConfig, p)
+			if notifier.Enabled() {
+				notifier.SetProgram(p)
+				orch.AddListener(notifier.Notify)
+			}
+			if collector != nil {
+				orch.AddObserver(collector)
+			}
+			if tracer != nil {
+				tracer.SetProgram(p)
+				orch.AddObserver(tracer)
+			}
 			m.SetOrchestrator(orch)
 
 			if err := orch.Start(); err != nil {
@@ -137,21 +312,28 @@ func runRwatch(cmd *cobra.Command, args []string) error {
 			}
 		}()
 	} else if !monitorOnly {
-		// LEGACY MODE - PTY wrapper (only supports Claude for now)
-		fmt.Println("🔧 Starting rwatch in legacy PTY mode...")
-		fmt.Println("   Note: Legacy mode only supports Claude CLI")
+		// LEGACY MODE - PTY wrapper around the CLI's interactive session
+		fmt.Printf("🔧 Starting rwatch in legacy PTY mode (CLI: %s)...\n", cliConfig.Backend)
+		if cliConfig.Model != "" {
+			fmt.Printf("   Model: %s\n", cliConfig.Model)
+		}
 		fmt.Println()
 
+		// The model needs the runner before the program runs to size its PTY
+		legacyConfig := cliConfig
+		legacyConfig.ExtraArgs = extraArgs
+		ptyRunner := runner.New(cli.NewCLIRunner(legacyConfig), p)
+		m.SetRunner(ptyRunner)
+
 		go func() {
-			claudeRunner := runner.New(extraArgs, p)
-			if err := claudeRunner.Start(); err != nil {
+			if err := ptyRunner.Start(); err != nil {
 				p.Send(model.ErrorMsg{Error: err})
 			}
 		}()
 	} else {
 		// MONITOR MODE - Just watch files
 		fmt.Println("👀 Starting rwatch in monitor mode...")
-		fmt.Println("   Watching PRD.md and progress.txt for changes")
+		fmt.Println("   Watching the PRD, progress.txt, HANDOFF.md, iteration logs and git for changes")
 		fmt.Println()
 	}
 
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/xaelophone/ralph-setup/internal/config"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
+)
+
+var (
+	lintJSON     bool
+	lintStrict   bool
+	lintMaxTitle int
+	convertForce bool
+)
+
+// newPRDCmd creates the `rwatch prd` command group
+func newPRDCmd() *cobra.Command {
+	prdCmd := &cobra.Command{
+		Use:   "prd",
+		Short: "Check and convert the PRD",
+	}
+
+	lintCmd := &cobra.Command{
+		Use:   "lint [file]",
+		Short: "Report PRD mistakes that make the loop skip or misread tasks",
+		Long: `Check the PRD (PRD.md, prd.yaml or prd.json, every file listed under
+"prd" in .ralph-config.json, or the given file) and report, with line numbers:
+
+  checkbox-syntax     Task lines the loop won't see: -[ ], * [ ], [x] without a bullet
+  missing-owner       Open tasks with neither 🤖 nor 🧑
+  both-owners         Open tasks marked both 🤖 and 🧑
+  duplicate-task      Tasks with the same title as an earlier one
+  unknown-dependency  "Depends on:" lines naming a task (title or #N) that doesn't exist
+  long-title          Titles over --max-title characters (warning)
+  nested-task         Nested 🤖 tasks, which the loop never picks (warning)
+  empty-section       Headings with nothing under them (warning)
+  invalid-field       Unknown owner, status or priority (prd.yaml/prd.json)
+
+Exits 1 if any errors are found (or warnings, with --strict), so it can
+gate CI.
+
+Examples:
+  rwatch prd lint
+  rwatch prd lint --json docs/PRD.md
+  rwatch prd lint --strict`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runPRDLint,
+	}
+	lintCmd.Flags().BoolVar(&lintJSON, "json", false, "Print problems as JSON")
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Exit non-zero on warnings too")
+	lintCmd.Flags().IntVar(&lintMaxTitle, "max-title", prd.DefaultMaxTitle, "Longest task title allowed, in characters")
+
+	convertCmd := &cobra.Command{
+		Use:   "convert <from> <to>",
+		Short: "Convert a PRD between Markdown, YAML and JSON",
+		Long: `Convert a PRD between PRD.md and the structured prd.yaml / prd.json formats.
+The format of each file is taken from its extension.
+
+Structured PRDs list tasks with explicit fields:
+
+  title: My App
+  tasks:
+    - id: create-api-endpoints
+      title: Create API endpoints
+      owner: ai                # ai or human
+      priority: P1             # P0 (highest) to P3, default P2
+      status: todo             # todo, done or blocked
+      section: Phase 1
+      depends_on: [data-models]
+      tags: [backend]
+      acceptance:
+        - GET /items returns 200
+
+From Markdown, nested lines become acceptance criteria, "Depends on:"
+lines become depends_on IDs, and P0-P3 / !! and #tag markers become
+priority and tags (and back). Other prose in PRD.md is not carried over.
+
+Examples:
+  rwatch prd convert PRD.md prd.yaml
+  rwatch prd convert prd.yaml PRD.md --force`,
+		Args: cobra.ExactArgs(2),
+		RunE: runPRDConvert,
+	}
+	convertCmd.Flags().BoolVarP(&convertForce, "force", "f", false, "Overwrite the destination if it exists")
+
+	prdCmd.AddCommand(lintCmd)
+	prdCmd.AddCommand(convertCmd)
+	return prdCmd
+}
+
+// prdProject builds the PRD file set from the "prd" and "epic_order" keys of
+// .ralph-config.json
+func prdProject(cfg config.ProjectConfig) (prd.Project, error) {
+	switch cfg.EpicOrder {
+	case "", prd.EpicOrderSequential, prd.EpicOrderPriority:
+	default:
+		return prd.Project{}, fmt.Errorf("invalid epic_order %q (want %q or %q)", cfg.EpicOrder, prd.EpicOrderSequential, prd.EpicOrderPriority)
+	}
+	return prd.Project{Patterns: cfg.PRD, Order: cfg.EpicOrder}, nil
+}
+
+func runPRDLint(cmd *cobra.Command, args []string) error {
+	project, err := prdProject(config.LoadProjectConfig())
+	if err != nil {
+		return err
+	}
+
+	files := []string{prd.Discover().Path()}
+	if len(args) == 1 {
+		files = args
+	} else if project.Multi() {
+		if files, err = project.Files(); err != nil {
+			return err
+		}
+	}
+
+	// Dependencies may name tasks in the project's other PRD files
+	all, _ := project.Tasks()
+
+	type report struct {
+		File     string        `json:"file"`
+		Problems []prd.Problem `json:"problems"`
+		Errors   int           `json:"errors"`
+		Warnings int           `json:"warnings"`
+	}
+	var reports []report
+	errors, warnings := 0, 0
+	for _, path := range files {
+		var related []parser.Task
+		for _, t := range all {
+			if t.File != path {
+				related = append(related, t)
+			}
+		}
+
+		problems, err := prd.Lint(path, prd.LintOptions{MaxTitle: lintMaxTitle, Related: related})
+		if err != nil {
+			return err
+		}
+
+		r := report{File: path, Problems: problems}
+		if r.Problems == nil {
+			r.Problems = []prd.Problem{}
+		}
+		for _, p := range problems {
+			if p.Severity == prd.SeverityError {
+				r.Errors++
+			} else {
+				r.Warnings++
+			}
+		}
+		errors += r.Errors
+		warnings += r.Warnings
+		reports = append(reports, r)
+	}
+
+	if lintJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		var out interface{} = reports
+		if len(reports) == 1 {
+			out = reports[0]
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range reports {
+			for _, p := range r.Problems {
+				fmt.Printf("%s:%d: %s: %s [%s]\n", r.File, p.Line, p.Severity, p.Message, p.Rule)
+			}
+			if len(r.Problems) == 0 {
+				fmt.Printf("✅ %s: no problems\n", r.File)
+			}
+		}
+		if errors+warnings > 0 {
+			fmt.Printf("\n%d error(s), %d warning(s)\n", errors, warnings)
+		}
+	}
+
+	if errors > 0 || (lintStrict && warnings > 0) {
+		// The report is the output; don't add cobra's usage or error line
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return fmt.Errorf("%d error(s), %d warning(s)", errors, warnings)
+	}
+	return nil
+}
+
+func runPRDConvert(cmd *cobra.Command, args []string) error {
+	from, to := args[0], args[1]
+	if _, err := os.Stat(to); err == nil && !convertForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", to)
+	}
+
+	warnings, err := prd.Convert(from, to)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Printf("⚠️  %s\n", w)
+	}
+	fmt.Printf("✅ Wrote %s\n", to)
+
+	if !prd.IsStructured(to) || prd.Discover().Path() == to {
+		return nil
+	}
+	fmt.Printf("   Remove %s to make rwatch use %s\n", prd.Discover().Path(), to)
+	return nil
+}
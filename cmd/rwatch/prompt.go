@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xaelophone/ralph-setup/internal/config"
+	"github.com/xaelophone/ralph-setup/internal/orchestrator"
+	"github.com/xaelophone/ralph-setup/internal/prompt"
+)
+
+var (
+	promptRender    bool
+	promptInit      bool
+	promptIteration int
+	promptTakeNotes bool
+	promptNotesSent bool
+)
+
+// newPromptCmd creates the `rwatch prompt` command for inspecting the prompt template
+func newPromptCmd() *cobra.Command {
+	promptCmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Show, initialise or preview the prompt template",
+		Long: `Show the prompt template used for each iteration.
+
+The template is read from .ralph/prompt.tmpl, falling back to the built-in
+default. It is a Go text/template with these fields:
+
+  .Iteration        Loop iteration, starting at 1
+  .Task             Current task title
+  .TaskFile         PRD holding the tasks (PRD.md, prd.yaml or prd.json)
+  .CompleteHint     How to mark a task done there ("- [x]" or "status: done")
+  .TaskContext      Detail lines and section of the task in PRD.md
+  .RecentProgress   Selected progress.txt entries
+  .PreviousFailure  Digest of the last failed attempt at this task
+  .Handoff          Tasks recorded as blocked in HANDOFF.md (list)
+  .GitStatus        git status --short of the working tree
+  .Notes            Notes added in the rwatch inbox (list, sent once)
+
+Examples:
+  rwatch prompt                 # Print the template in use
+  rwatch prompt --init          # Copy the default to .ralph/prompt.tmpl
+  rwatch prompt --render        # Print exactly what would be sent next
+
+ralph-loop sends inbox notes once by rendering with --take-notes, which sets
+them aside, and running --notes-sent after the CLI has read the prompt.
+Until then the same notes are taken again.`,
+		Args: cobra.NoArgs,
+		RunE: runPrompt,
+	}
+
+	promptCmd.Flags().BoolVarP(&promptRender, "render", "r", false, "Render the template for the next runnable task")
+	promptCmd.Flags().BoolVar(&promptInit, "init", false, "Write the default template to "+prompt.TemplateFile)
+	promptCmd.Flags().IntVar(&promptIteration, "iteration", 1, "Iteration number to render with")
+	promptCmd.Flags().BoolVar(&promptTakeNotes, "take-notes", false, "With --render, take the inbox notes for sending instead of only showing them")
+	promptCmd.Flags().BoolVar(&promptNotesSent, "notes-sent", false, "Discard the notes taken with --take-notes once the prompt was sent")
+
+	return promptCmd
+}
+
+func runPrompt(cmd *cobra.Command, args []string) error {
+	switch {
+	case promptInit:
+		if _, err := os.Stat(prompt.TemplateFile); err == nil {
+			return fmt.Errorf("%s already exists", prompt.TemplateFile)
+		}
+		if err := os.MkdirAll(filepath.Dir(prompt.TemplateFile), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(prompt.TemplateFile, []byte(prompt.Default()), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Wrote %s\n", prompt.TemplateFile)
+		return nil
+
+	case promptNotesSent:
+		prompt.NotesSent()
+		return nil
+
+	case promptRender:
+		project, err := prdProject(config.LoadProjectConfig())
+		if err != nil {
+			return err
+		}
+		orchConfig := orchestrator.DefaultConfig()
+		orchConfig.PRD = project
+		text, err := orchestrator.RenderPrompt(orchConfig, promptIteration, promptTakeNotes)
+		if err != nil {
+			return err
+		}
+		fmt.Print(text)
+		return nil
+
+	default:
+		src, name, err := prompt.Source()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "# %s\n", name)
+		fmt.Print(src)
+		return nil
+	}
+}
@@ -59,6 +59,28 @@ func (c *ClaudeCLI) BuildCommand(prompt string, workDir string) *exec.Cmd {
 	return cmd
 }
 
+// BuildInteractiveCommand creates an exec.Cmd for an interactive Claude session
+func (c *ClaudeCLI) BuildInteractiveCommand(workDir string) *exec.Cmd {
+	cmdPath := c.config.Command
+	if cmdPath == "" {
+		cmdPath = "claude"
+	}
+
+	// No prompt or output format: the user drives the session in the PTY
+	var args []string
+	if c.config.Model != "" {
+		args = append(args, "--model", c.config.Model)
+	}
+	args = append(args, c.config.ExtraArgs...)
+
+	cmd := exec.Command(cmdPath, args...)
+	if workDir != "" {
+		cmd.Dir = workDir
+	}
+
+	return cmd
+}
+
 // ClaudeEvent represents a Claude streaming JSON event
 type ClaudeEvent struct {
 	Type       string       `json:"type"`
@@ -68,6 +90,18 @@ type ClaudeEvent struct {
 	ToolResult *ClaudeResult `json:"tool_result,omitempty"`
 	Content    string       `json:"content,omitempty"`
 	Error      string       `json:"error,omitempty"`
+	Result     string       `json:"result,omitempty"`
+	IsError    bool         `json:"is_error,omitempty"`
+	CostUSD    float64      `json:"total_cost_usd,omitempty"`
+	Usage      *ClaudeUsage `json:"usage,omitempty"`
+}
+
+// ClaudeUsage represents token usage reported in the result event
+type ClaudeUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 }
 
 // ClaudeMsg represents an assistant message
@@ -125,6 +159,18 @@ func (c *ClaudeCLI) ParseEvent(line string) (*NormalizedEvent, error) {
 			normalized.IsError = event.ToolResult.IsError
 		}
 
+	case "result":
+		normalized.Type = EventTypeResult
+		normalized.Content = event.Result
+		normalized.IsError = event.IsError
+		normalized.Usage = &Usage{CostUSD: event.CostUSD}
+		if event.Usage != nil {
+			normalized.Usage.InputTokens = event.Usage.InputTokens +
+				event.Usage.CacheCreationInputTokens +
+				event.Usage.CacheReadInputTokens
+			normalized.Usage.OutputTokens = event.Usage.OutputTokens
+		}
+
 	case "error":
 		normalized.Type = EventTypeError
 		normalized.Content = event.Error
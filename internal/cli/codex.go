@@ -59,6 +59,28 @@ func (c *CodexCLI) BuildCommand(prompt string, workDir string) *exec.Cmd {
 	return cmd
 }
 
+// BuildInteractiveCommand creates an exec.Cmd for an interactive Codex session
+func (c *CodexCLI) BuildInteractiveCommand(workDir string) *exec.Cmd {
+	cmdPath := c.config.Command
+	if cmdPath == "" {
+		cmdPath = "codex"
+	}
+
+	// No prompt or output format: the user drives the session in the PTY
+	var args []string
+	if c.config.Model != "" {
+		args = append(args, "--model", c.config.Model)
+	}
+	args = append(args, c.config.ExtraArgs...)
+
+	cmd := exec.Command(cmdPath, args...)
+	if workDir != "" {
+		cmd.Dir = workDir
+	}
+
+	return cmd
+}
+
 // CodexEvent represents a Codex streaming JSON event
 // Codex uses a different event structure than Claude
 type CodexEvent struct {
@@ -67,6 +89,7 @@ type CodexEvent struct {
 	Item      *CodexItem      `json:"item,omitempty"`
 	Turn      *CodexTurn      `json:"turn,omitempty"`
 	Error     *CodexError     `json:"error,omitempty"`
+	Usage     *CodexUsage     `json:"usage,omitempty"`
 }
 
 // CodexItem represents an item in Codex output
@@ -95,6 +118,13 @@ type CodexTurn struct {
 	Status string `json:"status,omitempty"`
 }
 
+// CodexUsage represents token usage reported when a turn completes
+type CodexUsage struct {
+	InputTokens       int `json:"input_tokens"`
+	CachedInputTokens int `json:"cached_input_tokens"`
+	OutputTokens      int `json:"output_tokens"`
+}
+
 // CodexError represents an error
 type CodexError struct {
 	Message string `json:"message,omitempty"`
@@ -123,6 +153,13 @@ func (c *CodexCLI) ParseEvent(line string) (*NormalizedEvent, error) {
 
 	case "turn.completed":
 		normalized.Type = EventTypeTurnComplete
+		if event.Usage != nil {
+			// cached_input_tokens is a subset of input_tokens
+			normalized.Usage = &Usage{
+				InputTokens:  event.Usage.InputTokens,
+				OutputTokens: event.Usage.OutputTokens,
+			}
+		}
 
 	case "error":
 		normalized.Type = EventTypeError
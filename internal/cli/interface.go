@@ -13,6 +13,10 @@ type CLIRunner interface {
 	// BuildCommand creates an exec.Cmd configured for the CLI
 	BuildCommand(prompt string, workDir string) *exec.Cmd
 
+	// BuildInteractiveCommand creates an exec.Cmd that starts the CLI's
+	// interactive session, for running in a PTY (rwatch --legacy)
+	BuildInteractiveCommand(workDir string) *exec.Cmd
+
 	// ParseEvent parses a JSONL event line into a normalized event
 	// Returns nil if the line is not a valid event
 	ParseEvent(line string) (*NormalizedEvent, error)
@@ -32,10 +36,18 @@ type NormalizedEvent struct {
 	ToolID    string                 // Tool invocation ID
 	ToolInput map[string]interface{} // Tool input parameters
 	IsError   bool                   // Whether this represents an error
+	Usage     *Usage                 // Token usage and cost (for result events)
 	Raw       interface{}            // Original event for debugging
 	Timestamp time.Time              // Event timestamp
 }
 
+// Usage reports token consumption and cost for a run
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64 // Zero when the CLI doesn't report cost
+}
+
 // EventType represents normalized event types across CLIs
 type EventType string
 
@@ -52,6 +64,9 @@ const (
 	// EventTypeTurnComplete is emitted when a full turn completes
 	EventTypeTurnComplete EventType = "turn_complete"
 
+	// EventTypeResult is emitted with the final result and usage of a run
+	EventTypeResult EventType = "result"
+
 	// EventTypeError is emitted for errors
 	EventTypeError EventType = "error"
 
@@ -17,8 +17,8 @@ const (
 // CLIConfig holds CLI-specific configuration
 type CLIConfig struct {
 	Backend   CLIBackend `json:"cli"`
-	Command   string     `json:"command,omitempty"`   // Override command path
-	Model     string     `json:"model,omitempty"`     // Model to use
+	Command   string     `json:"command,omitempty"`    // Override command path
+	Model     string     `json:"model,omitempty"`      // Model to use
 	ExtraArgs []string   `json:"extra_args,omitempty"` // Additional CLI arguments
 }
 
@@ -31,8 +31,29 @@ func DefaultCLIConfig() CLIConfig {
 
 // ProjectConfig represents the .ralph-config.json file
 type ProjectConfig struct {
-	CLI   CLIBackend `json:"cli,omitempty"`
-	Model string     `json:"model,omitempty"`
+	CLI           CLIBackend            `json:"cli,omitempty"`
+	Model         string                `json:"model,omitempty"`
+	MaxBudgetUSD  float64               `json:"max_budget_usd,omitempty"`
+	MaxAttempts   int                   `json:"max_task_attempts,omitempty"` // Per-task failure limit; -1 = unlimited
+	PullRequest   PullRequestConfig     `json:"pull_request,omitempty"`
+	IssueTracker  IssueTrackerConfig    `json:"issue_tracker,omitempty"`
+	Notifications NotificationConfig    `json:"notifications,omitempty"`
+	TUIAlerts     TUINotificationConfig `json:"tui_notifications,omitempty"`
+	Trace         string                `json:"trace,omitempty"`         // OTLP/JSON trace file or OTLP HTTP endpoint
+	PRD           []string              `json:"prd,omitempty"`           // PRD files or globs, each an epic; default PRD.md
+	EpicOrder     string                `json:"epic_order,omitempty"`    // "sequential" (default) or "priority"
+	DetachKey     string                `json:"detach_key,omitempty"`    // Key that leaves attach mode in --legacy; default ctrl+]
+	AutoContinue  bool                  `json:"auto_continue,omitempty"` // In --legacy, send /clear and the next prompt on completion
+}
+
+// LoadProjectConfig loads .ralph-config.json, returning an empty config if it
+// is missing or invalid
+func LoadProjectConfig() ProjectConfig {
+	config, err := loadProjectConfig()
+	if err != nil {
+		return ProjectConfig{}
+	}
+	return *config
 }
 
 // LoadCLIConfig loads CLI configuration with the following precedence:
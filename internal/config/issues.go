@@ -0,0 +1,12 @@
+package config
+
+// IssueTrackerConfig selects the forge used by the issue bridge.
+// Configured under "issue_tracker" in .ralph-config.json; when omitted the
+// forge is detected from the origin remote:
+//
+//	{"issue_tracker": {"type": "gitlab", "url": "https://git.example.com/api/v4", "token_env": "MY_TOKEN"}}
+type IssueTrackerConfig struct {
+	Type     string `json:"type,omitempty"`      // github, gitlab or gitea
+	URL      string `json:"url,omitempty"`       // API endpoint override
+	TokenEnv string `json:"token_env,omitempty"` // Environment variable holding the API token
+}
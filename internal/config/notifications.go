@@ -0,0 +1,35 @@
+package config
+
+// NotificationConfig configures webhook notifications for session events.
+// Configured under "notifications" in .ralph-config.json:
+//
+//	{"notifications": {"webhooks": [
+//	  {"url": "https://hooks.slack.com/services/...", "format": "slack", "events": ["task_blocked", "session_finished"]},
+//	  {"url": "https://ntfy.sh/my-ralph", "format": "ntfy"}
+//	]}}
+type NotificationConfig struct {
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+	Retries  int             `json:"retries,omitempty"` // Retry attempts per delivery (default: 3)
+}
+
+// WebhookConfig describes a single webhook target
+type WebhookConfig struct {
+	URL      string            `json:"url"`
+	Format   string            `json:"format,omitempty"`   // json (default), slack, discord or ntfy
+	Events   []string          `json:"events,omitempty"`   // Event types to send (default: all)
+	Template string            `json:"template,omitempty"` // Optional text/template for the message text
+	Headers  map[string]string `json:"headers,omitempty"`  // Extra request headers (e.g. Authorization)
+}
+
+// Wants reports whether the webhook is subscribed to an event type
+func (w WebhookConfig) Wants(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package config
+
+// PullRequestConfig controls the optional end-of-session pull request.
+// Configured under "pull_request" in .ralph-config.json:
+//
+//	{"pull_request": {"enabled": true, "base": "main", "draft": true, "labels": ["ralph"]}}
+type PullRequestConfig struct {
+	Enabled bool     `json:"enabled"`
+	Base    string   `json:"base,omitempty"`   // Base branch (default: main)
+	Draft   bool     `json:"draft,omitempty"`  // Open as a draft PR
+	Labels  []string `json:"labels,omitempty"` // Labels to add to the PR
+}
+
+// BaseBranch returns the configured base branch or "main"
+func (c PullRequestConfig) BaseBranch() string {
+	if c.Base == "" {
+		return "main"
+	}
+	return c.Base
+}
@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+// Alert methods for TUI notifications
+const (
+	AlertBell   = "bell"   // Terminal bell (BEL)
+	AlertOSC9   = "osc9"   // OSC 9 desktop notification (iTerm2, WezTerm, Windows Terminal)
+	AlertOSC777 = "osc777" // OSC 777 desktop notification (urxvt, foot, Ghostty)
+	AlertTmux   = "tmux"   // tmux display-message in the status line
+)
+
+// TUINotificationConfig selects how rwatch alerts you for each event type.
+// Configured under "tui_notifications" in .ralph-config.json:
+//
+//	{"tui_notifications": {"blocked": ["bell", "osc9", "tmux"], "stopped": ["bell"], "error": ["bell", "tmux"]}}
+type TUINotificationConfig struct {
+	Blocked []string `json:"blocked,omitempty"` // A task output the blocked token
+	Stopped []string `json:"stopped,omitempty"` // The orchestrator loop ended
+	Error   []string `json:"error,omitempty"`   // The orchestrator reported an error
+}
+
+// Validate reports alert methods other than bell, osc9, osc777 and tmux, which
+// would otherwise be silently ignored
+func (c TUINotificationConfig) Validate() error {
+	for _, event := range []struct {
+		name    string
+		methods []string
+	}{{"blocked", c.Blocked}, {"stopped", c.Stopped}, {"error", c.Error}} {
+		for _, method := range event.methods {
+			switch method {
+			case AlertBell, AlertOSC9, AlertOSC777, AlertTmux:
+			default:
+				return fmt.Errorf("invalid tui_notifications.%s method %q (want %s, %s, %s or %s)",
+					event.name, method, AlertBell, AlertOSC9, AlertOSC777, AlertTmux)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package events
+
+import "time"
+
+// Type identifies a session lifecycle event
+type Type string
+
+const (
+	// TaskCompleted is emitted when an iteration outputs the completion token
+	TaskCompleted Type = "task_completed"
+
+	// TaskBlocked is emitted when an iteration outputs the blocked token
+	TaskBlocked Type = "task_blocked"
+
+	// IterationFailed is emitted when an iteration ends without a token
+	IterationFailed Type = "iteration_failed"
+
+	// SessionFinished is emitted when the loop ends normally
+	SessionFinished Type = "session_finished"
+
+	// SessionFailed is emitted when the loop aborts after repeated failures
+	SessionFailed Type = "session_failed"
+
+	// BudgetExhausted is emitted when the session cost reaches the configured budget
+	BudgetExhausted Type = "budget_exhausted"
+)
+
+// All lists every event type, for validating configuration
+var All = []Type{
+	TaskCompleted,
+	TaskBlocked,
+	IterationFailed,
+	SessionFinished,
+	SessionFailed,
+	BudgetExhausted,
+}
+
+// Event describes something that happened during an orchestrator session
+type Event struct {
+	Type           Type      `json:"type"`
+	Time           time.Time `json:"time"`
+	SessionID      string    `json:"session_id"`
+	Project        string    `json:"project"`
+	Iteration      int       `json:"iteration"`
+	Task           string    `json:"task,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	LogFile        string    `json:"log_file,omitempty"`
+	TasksCompleted int       `json:"tasks_completed"`
+	TasksRemaining int       `json:"tasks_remaining"`
+	CostUSD        float64   `json:"cost_usd,omitempty"`
+}
+
+// Listener receives events. Listeners are called synchronously from the
+// orchestrator loop and must not block.
+type Listener func(Event)
+
+// Title returns a short human-readable summary of the event
+func (e Event) Title() string {
+	switch e.Type {
+	case TaskCompleted:
+		return "✅ Task completed"
+	case TaskBlocked:
+		return "⛔ Task blocked"
+	case IterationFailed:
+		return "⚠️ Iteration failed"
+	case SessionFinished:
+		return "🏁 Session finished"
+	case SessionFailed:
+		return "❌ Session failed"
+	case BudgetExhausted:
+		return "💸 Budget exhausted"
+	default:
+		return string(e.Type)
+	}
+}
@@ -0,0 +1,49 @@
+package issues
+
+import (
+	"fmt"
+)
+
+// CompleteTask reports a completed PRD task back to the issue tracking it.
+// Sub-issues get a comment linking the commit and are closed; items from the
+// parent issue's own task list are ticked in its body. Tasks without an (#N)
+// suffix are ignored and report false.
+func CompleteTask(tracker Tracker, parent int, title, commit string) (bool, error) {
+	number, ok := TaskIssue(title)
+	if !ok {
+		return false, nil
+	}
+
+	comment := fmt.Sprintf("✅ Completed by ralph: %s", StripTaskIssue(title))
+	if commit != "" {
+		comment += fmt.Sprintf("\n\nCommit: %s", tracker.CommitURL(commit))
+	}
+
+	if number != parent {
+		if err := tracker.Comment(number, comment); err != nil {
+			return false, err
+		}
+		if err := tracker.CloseIssue(number); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	issue, err := tracker.GetIssue(parent)
+	if err != nil {
+		return false, err
+	}
+
+	body, ticked := TickChecklistItem(issue.Body, title)
+	if !ticked {
+		return false, fmt.Errorf("no open task list item matching %q in #%d", StripTaskIssue(title), parent)
+	}
+	if err := tracker.UpdateBody(parent, body); err != nil {
+		return false, err
+	}
+	if err := tracker.Comment(parent, comment); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,126 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Gitea is a minimal client for the Gitea (and Forgejo) REST API
+type Gitea struct {
+	BaseURL string // API endpoint, e.g. https://gitea.example.com/api/v1
+	Token   string
+	Repo    string // owner/name
+	HTTP    *http.Client
+}
+
+// NewGitea creates a Gitea client for the given owner/name repository
+func NewGitea(repo, baseURL, token string) *Gitea {
+	return &Gitea{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		Repo:    repo,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the forge name
+func (g *Gitea) Name() string {
+	return "Gitea"
+}
+
+// GetIssue fetches a single issue
+func (g *Gitea) GetIssue(number int) (*Issue, error) {
+	var issue Issue
+	if err := g.do("GET", g.issuePath(number), nil, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// SubIssues lists the issues an issue depends on. Gitea has no sub-issues,
+// so dependencies ("blocked by") stand in for them. Dependencies in other
+// repositories are skipped: sub-issues are addressed by number, which is
+// only unique within a repository.
+func (g *Gitea) SubIssues(number int) ([]Issue, error) {
+	var raw []struct {
+		Issue
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := g.do("GET", g.issuePath(number)+"/dependencies?limit=100", nil, &raw); err != nil {
+		return nil, err
+	}
+
+	var subs []Issue
+	for _, r := range raw {
+		if strings.EqualFold(r.Repository.FullName, g.Repo) {
+			subs = append(subs, r.Issue)
+		}
+	}
+	return subs, nil
+}
+
+// Comment posts a comment on an issue
+func (g *Gitea) Comment(number int, body string) error {
+	return g.do("POST", g.issuePath(number)+"/comments", map[string]string{"body": body}, nil)
+}
+
+// UpsertComment updates the latest comment containing marker, or posts a new one
+func (g *Gitea) UpsertComment(number int, marker, body string) error {
+	existing, err := allPages(50, func(page int) ([]IssueComment, error) {
+		var raw []rawComment
+		path := fmt.Sprintf("%s/comments?limit=50&page=%d", g.issuePath(number), page)
+		err := g.do("GET", path, nil, &raw)
+		return toComments(raw), err
+	})
+	if err != nil {
+		return err
+	}
+
+	if c := latestWithMarker(existing, marker); c != nil {
+		path := fmt.Sprintf("/repos/%s/issues/comments/%d", g.Repo, c.ID)
+		return g.do("PATCH", path, map[string]string{"body": body}, nil)
+	}
+	return g.Comment(number, body)
+}
+
+// CloseIssue closes an issue
+func (g *Gitea) CloseIssue(number int) error {
+	return g.do("PATCH", g.issuePath(number), map[string]string{"state": "closed"}, nil)
+}
+
+// UpdateBody replaces the body of an issue
+func (g *Gitea) UpdateBody(number int, body string) error {
+	return g.do("PATCH", g.issuePath(number), map[string]string{"body": body}, nil)
+}
+
+// IssueURL returns the web URL of an issue
+func (g *Gitea) IssueURL(number int) string {
+	return fmt.Sprintf("%s/%s/issues/%d", g.webURL(), g.Repo, number)
+}
+
+// CommitURL returns the web URL of a commit
+func (g *Gitea) CommitURL(sha string) string {
+	return fmt.Sprintf("%s/%s/commit/%s", g.webURL(), g.Repo, sha)
+}
+
+// webURL derives the web host from the API endpoint
+func (g *Gitea) webURL() string {
+	return strings.TrimSuffix(g.BaseURL, "/api/v1")
+}
+
+func (g *Gitea) issuePath(number int) string {
+	return fmt.Sprintf("/repos/%s/issues/%d", g.Repo, number)
+}
+
+// do performs an API request against the Gitea endpoint
+func (g *Gitea) do(method, path string, payload, out interface{}) error {
+	headers := map[string]string{}
+	if g.Token != "" {
+		headers["Authorization"] = "token " + g.Token
+	}
+	return doJSON(g.HTTP, method, g.BaseURL+path, headers, payload, out)
+}
@@ -0,0 +1,226 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultGitHubAPI is the public GitHub REST API endpoint
+const DefaultGitHubAPI = "https://api.github.com"
+
+// Issue represents an issue on any supported forge
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"` // open or closed
+	HTMLURL string `json:"html_url"`
+}
+
+// IsClosed returns whether the issue has been closed
+func (i Issue) IsClosed() bool {
+	return i.State == "closed"
+}
+
+// GitHub is a minimal client for the GitHub REST API
+type GitHub struct {
+	BaseURL string // API endpoint, overridable for GitHub Enterprise or local stand-ins
+	Token   string
+	Repo    string // owner/name
+	HTTP    *http.Client
+}
+
+// NewGitHub creates a GitHub client for the given owner/name repository.
+// The API endpoint can be overridden with GITHUB_API_URL.
+func NewGitHub(repo, token string) *GitHub {
+	baseURL := os.Getenv("GITHUB_API_URL")
+	if baseURL == "" {
+		baseURL = DefaultGitHubAPI
+	}
+
+	return &GitHub{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		Repo:    repo,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GitHubToken returns a token from GITHUB_TOKEN, GH_TOKEN or the gh CLI
+func GitHubToken() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	if token := os.Getenv("GH_TOKEN"); token != "" {
+		return token
+	}
+
+	out, err := exec.Command("gh", "auth", "token").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Name returns the forge name
+func (g *GitHub) Name() string {
+	return "GitHub"
+}
+
+// GetIssue fetches a single issue
+func (g *GitHub) GetIssue(number int) (*Issue, error) {
+	var issue Issue
+	if err := g.do("GET", g.issuePath(number), nil, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// SubIssues lists the sub-issues linked to an issue
+func (g *GitHub) SubIssues(number int) ([]Issue, error) {
+	var subs []Issue
+	path := g.issuePath(number) + "/sub_issues?per_page=100"
+	if err := g.do("GET", path, nil, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// Comment posts a comment on an issue
+func (g *GitHub) Comment(number int, body string) error {
+	payload := map[string]string{"body": body}
+	return g.do("POST", g.issuePath(number)+"/comments", payload, nil)
+}
+
+// UpsertComment updates the latest comment containing marker, or posts a new one
+func (g *GitHub) UpsertComment(number int, marker, body string) error {
+	existing, err := allPages(100, func(page int) ([]IssueComment, error) {
+		var raw []rawComment
+		path := fmt.Sprintf("%s/comments?per_page=100&page=%d", g.issuePath(number), page)
+		err := g.do("GET", path, nil, &raw)
+		return toComments(raw), err
+	})
+	if err != nil {
+		return err
+	}
+
+	if c := latestWithMarker(existing, marker); c != nil {
+		path := fmt.Sprintf("/repos/%s/issues/comments/%d", g.Repo, c.ID)
+		return g.do("PATCH", path, map[string]string{"body": body}, nil)
+	}
+	return g.Comment(number, body)
+}
+
+// CloseIssue closes an issue as completed
+func (g *GitHub) CloseIssue(number int) error {
+	payload := map[string]string{"state": "closed", "state_reason": "completed"}
+	return g.do("PATCH", g.issuePath(number), payload, nil)
+}
+
+// UpdateBody replaces the body of an issue
+func (g *GitHub) UpdateBody(number int, body string) error {
+	payload := map[string]string{"body": body}
+	return g.do("PATCH", g.issuePath(number), payload, nil)
+}
+
+// PullRequest represents a GitHub pull request
+type PullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Draft   bool   `json:"draft"`
+	HTMLURL string `json:"html_url"`
+}
+
+// FindPullRequest returns the open pull request from head into base, or nil
+func (g *GitHub) FindPullRequest(head, base string) (*PullRequest, error) {
+	owner := strings.SplitN(g.Repo, "/", 2)[0]
+	query := url.Values{}
+	query.Set("state", "open")
+	query.Set("head", owner+":"+head)
+	query.Set("base", base)
+
+	var prs []PullRequest
+	if err := g.do("GET", "/repos/"+g.Repo+"/pulls?"+query.Encode(), nil, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &prs[0], nil
+}
+
+// CreatePullRequest opens a pull request from head into base
+func (g *GitHub) CreatePullRequest(title, body, head, base string, draft bool) (*PullRequest, error) {
+	payload := map[string]interface{}{
+		"title": title,
+		"body":  body,
+		"head":  head,
+		"base":  base,
+		"draft": draft,
+	}
+
+	var pr PullRequest
+	if err := g.do("POST", "/repos/"+g.Repo+"/pulls", payload, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequest replaces the title and body of a pull request
+func (g *GitHub) UpdatePullRequest(number int, title, body string) (*PullRequest, error) {
+	payload := map[string]string{"title": title, "body": body}
+
+	var pr PullRequest
+	path := fmt.Sprintf("/repos/%s/pulls/%d", g.Repo, number)
+	if err := g.do("PATCH", path, payload, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// AddLabels adds labels to an issue or pull request
+func (g *GitHub) AddLabels(number int, labels []string) error {
+	payload := map[string][]string{"labels": labels}
+	return g.do("POST", g.issuePath(number)+"/labels", payload, nil)
+}
+
+// CommitURL returns the web URL of a commit in the repository
+func (g *GitHub) CommitURL(sha string) string {
+	return g.webURL() + "/" + g.Repo + "/commit/" + sha
+}
+
+// IssueURL returns the web URL of an issue in the repository
+func (g *GitHub) IssueURL(number int) string {
+	return fmt.Sprintf("%s/%s/issues/%d", g.webURL(), g.Repo, number)
+}
+
+// webURL derives the web host from the API endpoint
+func (g *GitHub) webURL() string {
+	if g.BaseURL == DefaultGitHubAPI {
+		return "https://github.com"
+	}
+	// GitHub Enterprise serves the API under /api/v3
+	return strings.TrimSuffix(g.BaseURL, "/api/v3")
+}
+
+func (g *GitHub) issuePath(number int) string {
+	return fmt.Sprintf("/repos/%s/issues/%d", g.Repo, number)
+}
+
+// do performs an API request against the repository's GitHub endpoint
+func (g *GitHub) do(method, path string, payload, out interface{}) error {
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if g.Token != "" {
+		headers["Authorization"] = "Bearer " + g.Token
+	}
+	return doJSON(g.HTTP, method, g.BaseURL+path, headers, payload, out)
+}
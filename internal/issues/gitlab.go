@@ -0,0 +1,153 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GitLab is a minimal client for the GitLab REST API (v4)
+type GitLab struct {
+	BaseURL string // API endpoint, e.g. https://gitlab.com/api/v4
+	Token   string
+	Repo    string // Project path: group/subgroup/name
+	HTTP    *http.Client
+}
+
+// NewGitLab creates a GitLab client for the given project path
+func NewGitLab(repo, baseURL, token string) *GitLab {
+	return &GitLab{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		Repo:    repo,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// gitlabIssue is the GitLab issue representation
+type gitlabIssue struct {
+	IID         int    `json:"iid"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"` // opened or closed
+	WebURL      string `json:"web_url"`
+	ProjectID   int    `json:"project_id"`
+	LinkType    string `json:"link_type"` // On linked issues: relates_to, blocks or is_blocked_by
+}
+
+func (i gitlabIssue) toIssue() Issue {
+	state := i.State
+	if state == "opened" {
+		state = "open"
+	}
+	return Issue{
+		Number:  i.IID,
+		Title:   i.Title,
+		Body:    i.Description,
+		State:   state,
+		HTMLURL: i.WebURL,
+	}
+}
+
+// Name returns the forge name
+func (g *GitLab) Name() string {
+	return "GitLab"
+}
+
+// GetIssue fetches a single issue by its project-scoped IID
+func (g *GitLab) GetIssue(number int) (*Issue, error) {
+	var raw gitlabIssue
+	if err := g.do("GET", g.issuePath(number), nil, &raw); err != nil {
+		return nil, err
+	}
+	issue := raw.toIssue()
+	return &issue, nil
+}
+
+// SubIssues lists the issues an issue is blocked by. GitLab's REST API has
+// no parent/child hierarchy, so "is blocked by" links stand in for
+// sub-issues. Issues in other projects are skipped: sub-issues are addressed
+// by IID, which is only unique within a project.
+func (g *GitLab) SubIssues(number int) ([]Issue, error) {
+	var parent gitlabIssue
+	if err := g.do("GET", g.issuePath(number), nil, &parent); err != nil {
+		return nil, err
+	}
+	var raw []gitlabIssue
+	if err := g.do("GET", g.issuePath(number)+"/links", nil, &raw); err != nil {
+		return nil, err
+	}
+
+	var subs []Issue
+	for _, r := range raw {
+		if r.LinkType == "is_blocked_by" && r.ProjectID == parent.ProjectID {
+			subs = append(subs, r.toIssue())
+		}
+	}
+	return subs, nil
+}
+
+// Comment posts a note on an issue
+func (g *GitLab) Comment(number int, body string) error {
+	return g.do("POST", g.issuePath(number)+"/notes", map[string]string{"body": body}, nil)
+}
+
+// UpsertComment updates the latest note containing marker, or posts a new one
+func (g *GitLab) UpsertComment(number int, marker, body string) error {
+	existing, err := allPages(100, func(page int) ([]IssueComment, error) {
+		var raw []rawComment
+		path := fmt.Sprintf("%s/notes?sort=asc&per_page=100&page=%d", g.issuePath(number), page)
+		err := g.do("GET", path, nil, &raw)
+		return toComments(raw), err
+	})
+	if err != nil {
+		return err
+	}
+
+	if c := latestWithMarker(existing, marker); c != nil {
+		path := fmt.Sprintf("%s/notes/%d", g.issuePath(number), c.ID)
+		return g.do("PUT", path, map[string]string{"body": body}, nil)
+	}
+	return g.Comment(number, body)
+}
+
+// CloseIssue closes an issue
+func (g *GitLab) CloseIssue(number int) error {
+	return g.do("PUT", g.issuePath(number), map[string]string{"state_event": "close"}, nil)
+}
+
+// UpdateBody replaces the description of an issue
+func (g *GitLab) UpdateBody(number int, body string) error {
+	return g.do("PUT", g.issuePath(number), map[string]string{"description": body}, nil)
+}
+
+// IssueURL returns the web URL of an issue
+func (g *GitLab) IssueURL(number int) string {
+	return fmt.Sprintf("%s/%s/-/issues/%d", g.webURL(), g.Repo, number)
+}
+
+// CommitURL returns the web URL of a commit
+func (g *GitLab) CommitURL(sha string) string {
+	return fmt.Sprintf("%s/%s/-/commit/%s", g.webURL(), g.Repo, sha)
+}
+
+// webURL derives the web host from the API endpoint
+func (g *GitLab) webURL() string {
+	return strings.TrimSuffix(g.BaseURL, "/api/v4")
+}
+
+// issuePath addresses an issue by URL-encoded project path and IID
+func (g *GitLab) issuePath(number int) string {
+	return fmt.Sprintf("/projects/%s/issues/%d", url.PathEscape(g.Repo), number)
+}
+
+// do performs an API request against the GitLab endpoint
+func (g *GitLab) do(method, path string, payload, out interface{}) error {
+	headers := map[string]string{}
+	if g.Token != "" {
+		headers["PRIVATE-TOKEN"] = g.Token
+	}
+	return doJSON(g.HTTP, method, g.BaseURL+path, headers, payload, out)
+}
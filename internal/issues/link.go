@@ -0,0 +1,77 @@
+package issues
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// LinkFile stores the issue number the project is linked to (shared with ralph-gh)
+const LinkFile = ".ralph-issue"
+
+// Link identifies the issue a project is linked to
+type Link struct {
+	Host  string // Remote host, used to detect the forge
+	Repo  string // Repository path: owner/name or group/subgroup/name
+	Issue int
+}
+
+// LoadLink reads the linked issue from .ralph-issue and the repo from git origin
+func LoadLink() (*Link, error) {
+	data, err := os.ReadFile(LinkFile)
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid issue number in %s: %w", LinkFile, err)
+	}
+
+	link, err := LinkFromGit()
+	if err != nil {
+		return nil, err
+	}
+	link.Issue = number
+
+	return link, nil
+}
+
+// LinkFromGit returns the host and repository of the origin remote
+func LinkFromGit() (*Link, error) {
+	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err != nil {
+		return nil, fmt.Errorf("no git remote 'origin' found")
+	}
+
+	host, repo, err := ParseRemote(string(out))
+	if err != nil {
+		return nil, err
+	}
+	return &Link{Host: host, Repo: repo}, nil
+}
+
+// SaveLink writes the linked issue number to .ralph-issue
+func SaveLink(number int) error {
+	return os.WriteFile(LinkFile, []byte(strconv.Itoa(number)+"\n"), 0644)
+}
+
+// RepoFromGit returns the repository path of the origin remote
+func RepoFromGit() (string, error) {
+	link, err := LinkFromGit()
+	if err != nil {
+		return "", err
+	}
+	return link.Repo, nil
+}
+
+// HeadCommit returns the current HEAD commit hash
+func HeadCommit() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
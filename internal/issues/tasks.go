@@ -0,0 +1,170 @@
+package issues
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Task is a PRD task derived from an issue
+type Task struct {
+	Title    string
+	Issue    int // Issue number that tracks this task (sub-issue or the parent itself)
+	Complete bool
+}
+
+var (
+	// Match task list items: - [ ] text, * [x] text
+	checklistPattern = regexp.MustCompile(`^(\s*[-*]\s*\[)([ xX])(\]\s*)(.+?)\s*$`)
+	// Match a leading issue reference in a task list item: #123 or owner/repo#123
+	checklistRefPattern = regexp.MustCompile(`^(?:[\w.-]+/[\w.-]+)?#(\d+)\b\s*(.*)$`)
+	// Match the issue reference suffix on a PRD task: Title (#123)
+	taskRefPattern = regexp.MustCompile(`\s*\(#(\d+)\)\s*$`)
+)
+
+// TasksFromIssue builds PRD tasks from an issue's sub-issues and its own task list.
+// Sub-issues carry their own number; task list items carry the parent's number
+// unless they reference another issue.
+func TasksFromIssue(issue *Issue, subIssues []Issue) []Task {
+	var tasks []Task
+	seen := make(map[int]bool)
+
+	for _, sub := range subIssues {
+		tasks = append(tasks, Task{
+			Title:    strings.TrimSpace(sub.Title),
+			Issue:    sub.Number,
+			Complete: sub.IsClosed(),
+		})
+		seen[sub.Number] = true
+	}
+
+	for _, line := range strings.Split(issue.Body, "\n") {
+		match := checklistPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+
+		task := Task{
+			Title:    match[4],
+			Issue:    issue.Number,
+			Complete: match[2] != " ",
+		}
+
+		if ref := checklistRefPattern.FindStringSubmatch(task.Title); ref != nil {
+			number, _ := strconv.Atoi(ref[1])
+			if seen[number] {
+				continue // Already listed as a sub-issue
+			}
+			task.Issue = number
+			if ref[2] != "" {
+				task.Title = ref[2]
+			}
+			seen[number] = true
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks
+}
+
+// FormatTask renders a task as a PRD.md checkbox line carrying its issue number
+func FormatTask(t Task) string {
+	box := "[ ]"
+	if t.Complete {
+		box = "[x]"
+	}
+	return fmt.Sprintf("- %s 🤖 %s (#%d)", box, t.Title, t.Issue)
+}
+
+// TaskIssue extracts the issue number from a PRD task title ending in (#123)
+func TaskIssue(title string) (int, bool) {
+	match := taskRefPattern.FindStringSubmatch(title)
+	if match == nil {
+		return 0, false
+	}
+	number, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// StripTaskIssue removes the (#123) suffix from a PRD task title
+func StripTaskIssue(title string) string {
+	return taskRefPattern.ReplaceAllString(title, "")
+}
+
+// TickChecklistItem checks off the task list item matching title in an issue body.
+// Returns the updated body and whether an item was ticked.
+func TickChecklistItem(body, title string) (string, bool) {
+	lines := strings.Split(body, "\n")
+	want := normalizeTitle(title)
+
+	for i, line := range lines {
+		match := checklistPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil || match[2] != " " {
+			continue
+		}
+		if normalizeTitle(match[4]) != want {
+			continue
+		}
+		lines[i] = strings.Replace(line, match[1]+" "+match[3], match[1]+"x"+match[3], 1)
+		return strings.Join(lines, "\n"), true
+	}
+
+	return body, false
+}
+
+// normalizeTitle makes titles comparable across PRD and issue formatting
+func normalizeTitle(s string) string {
+	s = StripTaskIssue(s)
+	s = strings.ReplaceAll(s, "🤖", "")
+	s = strings.ReplaceAll(s, "🧑", "")
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// RenderPRD builds PRD.md content for an issue and its tasks
+func RenderPRD(issue *Issue, issueURL string, tasks []Task) string {
+	var sb strings.Builder
+
+	sb.WriteString("# " + issue.Title + "\n\n")
+	sb.WriteString(fmt.Sprintf("> Linked to: [Issue #%d](%s)\n", issue.Number, issueURL))
+	sb.WriteString("> Legend: 🤖 = AI task | 🧑 = Human task\n\n")
+	sb.WriteString("---\n\n")
+	sb.WriteString(stripChecklist(issue.Body) + "\n\n")
+	sb.WriteString("---\n\n")
+	sb.WriteString("## Implementation Tasks\n\n")
+
+	if len(tasks) == 0 {
+		sb.WriteString(`<!--
+Break the above into atomic 15-30 min tasks.
+Mark each with 🤖 (Claude can do) or 🧑 (human required).
+Tasks ending in (#N) are closed on GitHub when completed.
+-->
+
+- [ ] 🤖 TODO: Break this issue into atomic tasks
+`)
+		return sb.String()
+	}
+
+	sb.WriteString("<!-- Tasks ending in (#N) are closed/ticked on GitHub when completed. -->\n\n")
+	for _, t := range tasks {
+		sb.WriteString(FormatTask(t) + "\n")
+	}
+
+	return sb.String()
+}
+
+// stripChecklist removes task list items from an issue body (they become PRD tasks)
+func stripChecklist(body string) string {
+	var kept []string
+	for _, line := range strings.Split(body, "\n") {
+		if checklistPattern.MatchString(strings.TrimRight(line, "\r")) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
@@ -0,0 +1,243 @@
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/config"
+)
+
+// Tracker abstracts the issue operations used by the ralph issue bridge
+type Tracker interface {
+	// Name returns the forge name for display purposes
+	Name() string
+
+	// GetIssue fetches a single issue
+	GetIssue(number int) (*Issue, error)
+
+	// SubIssues lists the issues that break down the given issue
+	SubIssues(number int) ([]Issue, error)
+
+	// Comment posts a new comment on an issue
+	Comment(number int, body string) error
+
+	// UpsertComment updates the latest comment containing marker, or posts a new one
+	UpsertComment(number int, marker, body string) error
+
+	// CloseIssue closes an issue as completed
+	CloseIssue(number int) error
+
+	// UpdateBody replaces the body (description) of an issue
+	UpdateBody(number int, body string) error
+
+	// IssueURL returns the web URL of an issue
+	IssueURL(number int) string
+
+	// CommitURL returns the web URL of a commit
+	CommitURL(sha string) string
+}
+
+// IssueComment represents a comment on an issue
+type IssueComment struct {
+	ID   int64
+	Body string
+}
+
+// Forge identifies the hosting platform of a repository
+type Forge string
+
+const (
+	ForgeGitHub Forge = "github"
+	ForgeGitLab Forge = "gitlab"
+	ForgeGitea  Forge = "gitea"
+)
+
+// IsValid checks if the forge is supported
+func (f Forge) IsValid() bool {
+	return f == ForgeGitHub || f == ForgeGitLab || f == ForgeGitea
+}
+
+var (
+	// Match scp-style SSH remotes: git@host:path.git
+	scpRemotePattern = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+)
+
+// ParseRemote splits a git remote URL into host and repository path.
+// Handles git@host:owner/repo.git, ssh://git@host:22/owner/repo.git and
+// https://host:8443/group/subgroup/repo.git
+func ParseRemote(remote string) (host, path string, err error) {
+	remote = strings.TrimSpace(remote)
+
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", "", err
+		}
+		// An ssh:// port is the SSH server's, not the web server's
+		host, path = u.Host, u.Path
+		if strings.Contains(u.Scheme, "ssh") {
+			host = u.Hostname()
+		}
+	} else if match := scpRemotePattern.FindStringSubmatch(remote); match != nil {
+		host, path = match[1], match[2]
+	} else {
+		return "", "", fmt.Errorf("cannot parse repository from remote %q", remote)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(path, "/") {
+		return "", "", fmt.Errorf("cannot parse repository from remote %q", remote)
+	}
+	return host, path, nil
+}
+
+// DetectForge guesses the forge from the remote host
+func DetectForge(host string) Forge {
+	h := strings.ToLower(host)
+	switch {
+	case strings.Contains(h, "gitlab"):
+		return ForgeGitLab
+	case strings.Contains(h, "gitea"), strings.Contains(h, "codeberg"), strings.Contains(h, "forgejo"):
+		return ForgeGitea
+	default:
+		return ForgeGitHub
+	}
+}
+
+// NewTracker creates the tracker for a linked project. The forge is taken
+// from the "issue_tracker" section of .ralph-config.json when set, otherwise
+// detected from the remote host.
+func NewTracker(link *Link, cfg config.IssueTrackerConfig) (Tracker, error) {
+	forge := Forge(cfg.Type)
+	if forge == "" {
+		forge = DetectForge(link.Host)
+	}
+	if !forge.IsValid() {
+		return nil, fmt.Errorf("invalid issue tracker: %s (use 'github', 'gitlab' or 'gitea')", forge)
+	}
+
+	switch forge {
+	case ForgeGitLab:
+		return NewGitLab(link.Repo, apiBase(cfg.URL, link.Host, "/api/v4"), trackerToken(cfg, "GITLAB_TOKEN")), nil
+	case ForgeGitea:
+		return NewGitea(link.Repo, apiBase(cfg.URL, link.Host, "/api/v1"), trackerToken(cfg, "GITEA_TOKEN")), nil
+	default:
+		gh := NewGitHub(link.Repo, trackerToken(cfg, ""))
+		if cfg.URL != "" {
+			gh.BaseURL = strings.TrimSuffix(cfg.URL, "/")
+		} else if link.Host != "" && link.Host != "github.com" && gh.BaseURL == DefaultGitHubAPI {
+			gh.BaseURL = "https://" + link.Host + "/api/v3" // GitHub Enterprise
+		}
+		return gh, nil
+	}
+}
+
+// apiBase returns the configured API URL or derives it from the remote host
+func apiBase(configured, host, suffix string) string {
+	if configured != "" {
+		return strings.TrimSuffix(configured, "/")
+	}
+	return "https://" + host + suffix
+}
+
+// trackerToken resolves the API token: token_env from config, then the
+// forge's default variable (or the GitHub token chain)
+func trackerToken(cfg config.IssueTrackerConfig, envVar string) string {
+	if cfg.TokenEnv != "" {
+		if token := os.Getenv(cfg.TokenEnv); token != "" {
+			return token
+		}
+	}
+	if envVar == "" {
+		return GitHubToken()
+	}
+	return os.Getenv(envVar)
+}
+
+// doJSON performs a REST request, encoding payload as JSON and decoding into out
+func doJSON(client *http.Client, method, endpoint string, headers map[string]string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// allPages collects comments page by page from get, which fetches page
+// (from 1) of size comments; the first short page is the last
+func allPages(size int, get func(page int) ([]IssueComment, error)) ([]IssueComment, error) {
+	var all []IssueComment
+	for page := 1; ; page++ {
+		batch, err := get(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, batch...)
+		if len(batch) < size {
+			return all, nil
+		}
+	}
+}
+
+// rawComment is a comment or note as the forges return it
+type rawComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// toComments converts raw comments
+func toComments(raw []rawComment) []IssueComment {
+	comments := make([]IssueComment, len(raw))
+	for i, c := range raw {
+		comments[i] = IssueComment{ID: c.ID, Body: c.Body}
+	}
+	return comments
+}
+
+// latestWithMarker returns the most recent comment containing marker
+func latestWithMarker(comments []IssueComment, marker string) *IssueComment {
+	for i := len(comments) - 1; i >= 0; i-- {
+		if strings.Contains(comments[i].Body, marker) {
+			return &comments[i]
+		}
+	}
+	return nil
+}
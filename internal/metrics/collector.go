@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/xaelophone/ralph-setup/internal/orchestrator"
+)
+
+// durationBuckets are the upper bounds (seconds) of the iteration duration
+// histogram. Iterations range from a quick fix to an hour-long refactor.
+var durationBuckets = []float64{30, 60, 120, 300, 600, 900, 1800, 3600}
+
+// sessionStates are exported as a one-hot gauge so dashboards can show the
+// current state of each loop
+var sessionStates = []orchestrator.SessionStatus{
+	orchestrator.SessionStatusRunning,
+	orchestrator.SessionStatusCompleted,
+	orchestrator.SessionStatusInterrupted,
+	orchestrator.SessionStatusFailed,
+	orchestrator.SessionStatusBudgetExhausted,
+}
+
+// toolKey labels tool call counts
+type toolKey struct {
+	tool  string
+	error bool
+}
+
+// Collector records orchestrator activity as Prometheus metrics. It
+// implements orchestrator.Observer.
+type Collector struct {
+	mu sync.Mutex
+
+	sessionID string
+	project   string
+	backend   string
+	state     orchestrator.SessionStatus
+
+	iteration           int
+	iterations          map[orchestrator.IterationStatus]int
+	tasksCompleted      int
+	tasksRemaining      int
+	consecutiveFailures int
+
+	durationCounts []int // Per bucket, cumulative on export
+	durationSum    float64
+	durationCount  int
+
+	toolCalls map[toolKey]int
+
+	inputTokens  int
+	outputTokens int
+	costUSD      float64
+}
+
+// NewCollector creates a collector for a loop using the given CLI backend
+func NewCollector(backend string) *Collector {
+	return &Collector{
+		backend:        backend,
+		iterations:     make(map[orchestrator.IterationStatus]int),
+		durationCounts: make([]int, len(durationBuckets)),
+		toolCalls:      make(map[toolKey]int),
+	}
+}
+
+// IterationStarted records the current iteration and task queue
+func (c *Collector) IterationStarted(session *orchestrator.Session, task string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updateSession(session)
+}
+
+// ToolFinished counts a tool call by name and outcome
+func (c *Collector) ToolFinished(session *orchestrator.Session, trace orchestrator.SubagentTrace) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolCalls[toolKey{tool: trace.Type, error: trace.Status == orchestrator.SubagentStatusError}]++
+	c.updateSession(session)
+}
+
+// IterationFinished counts the iteration and observes its duration
+func (c *Collector) IterationFinished(session *orchestrator.Session, result orchestrator.IterationResult, consecutiveFailures int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.iterations[result.Status]++
+	c.consecutiveFailures = consecutiveFailures
+
+	seconds := result.Duration.Seconds()
+	c.durationSum += seconds
+	c.durationCount++
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			c.durationCounts[i]++
+			break
+		}
+	}
+
+	c.updateSession(session)
+}
+
+// SessionEnded records the final session state
+func (c *Collector) SessionEnded(session *orchestrator.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updateSession(session)
+}
+
+// updateSession copies the session totals. Callers must hold c.mu.
+func (c *Collector) updateSession(s *orchestrator.Session) {
+	c.sessionID = s.ID
+	c.project = filepath.Base(s.WorkingDir)
+	c.state = s.Status
+	c.iteration = s.Iteration
+	c.tasksCompleted = s.TasksCompleted
+	c.tasksRemaining = s.TasksRemaining
+	c.inputTokens = s.InputTokens
+	c.outputTokens = s.OutputTokens
+	c.costUSD = s.CostUSD
+}
+
+// Write renders all metrics in the Prometheus text exposition format
+func (c *Collector) Write(w *Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w.Help("ralph_session_info", "gauge", "Information about the running ralph session.")
+	w.Sample("ralph_session_info", Labels{"session_id", c.sessionID, "project", c.project, "backend", c.backend}, 1)
+
+	w.Help("ralph_session_state", "gauge", "Current session state (1 for the active state).")
+	for _, state := range sessionStates {
+		value := 0.0
+		if c.state == state {
+			value = 1
+		}
+		w.Sample("ralph_session_state", Labels{"state", string(state)}, value)
+	}
+
+	w.Help("ralph_iteration", "gauge", "Current iteration number.")
+	w.Sample("ralph_iteration", nil, float64(c.iteration))
+
+	w.Help("ralph_iterations_total", "counter", "Iterations finished, by outcome.")
+	for _, status := range []orchestrator.IterationStatus{
+		orchestrator.IterationStatusComplete,
+		orchestrator.IterationStatusBlocked,
+		orchestrator.IterationStatusFailed,
+		orchestrator.IterationStatusTimeout,
+	} {
+		w.Sample("ralph_iterations_total", Labels{"status", string(status)}, float64(c.iterations[status]))
+	}
+
+	w.Help("ralph_iteration_duration_seconds", "histogram", "Wall-clock duration of iterations.")
+	cumulative := 0
+	for i, bound := range durationBuckets {
+		cumulative += c.durationCounts[i]
+		w.Sample("ralph_iteration_duration_seconds_bucket", Labels{"le", formatFloat(bound)}, float64(cumulative))
+	}
+	w.Sample("ralph_iteration_duration_seconds_bucket", Labels{"le", "+Inf"}, float64(c.durationCount))
+	w.Sample("ralph_iteration_duration_seconds_sum", nil, c.durationSum)
+	w.Sample("ralph_iteration_duration_seconds_count", nil, float64(c.durationCount))
+
+	w.Help("ralph_tasks_completed_total", "counter", "Tasks completed this session.")
+	w.Sample("ralph_tasks_completed_total", nil, float64(c.tasksCompleted))
+
+	w.Help("ralph_tasks_remaining", "gauge", "Open 🤖 tasks in PRD.md.")
+	w.Sample("ralph_tasks_remaining", nil, float64(c.tasksRemaining))
+
+	w.Help("ralph_consecutive_failures", "gauge", "Iterations in a row that ended without a completion token.")
+	w.Sample("ralph_consecutive_failures", nil, float64(c.consecutiveFailures))
+
+	w.Help("ralph_tool_calls_total", "counter", "Tool calls made by the agent, by tool and outcome.")
+	keys := make([]toolKey, 0, len(c.toolCalls))
+	for k := range c.toolCalls {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].tool != keys[j].tool {
+			return keys[i].tool < keys[j].tool
+		}
+		return !keys[i].error && keys[j].error
+	})
+	for _, k := range keys {
+		errLabel := "false"
+		if k.error {
+			errLabel = "true"
+		}
+		w.Sample("ralph_tool_calls_total", Labels{"tool", k.tool, "error", errLabel}, float64(c.toolCalls[k]))
+	}
+
+	w.Help("ralph_tokens_total", "counter", "Tokens reported by the CLI backend.")
+	w.Sample("ralph_tokens_total", Labels{"direction", "input"}, float64(c.inputTokens))
+	w.Sample("ralph_tokens_total", Labels{"direction", "output"}, float64(c.outputTokens))
+
+	w.Help("ralph_cost_usd_total", "counter", "Cost in USD reported by the CLI backend.")
+	w.Sample("ralph_cost_usd_total", nil, c.costUSD)
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Labels is a flat list of name/value pairs, kept in order for stable output
+type Labels []string
+
+// Writer renders the Prometheus text exposition format (version 0.0.4)
+type Writer struct {
+	buf bytes.Buffer
+}
+
+// Help writes the HELP and TYPE lines for a metric family
+func (w *Writer) Help(name, metricType, help string) {
+	fmt.Fprintf(&w.buf, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(&w.buf, "# TYPE %s %s\n", name, metricType)
+}
+
+// Sample writes a single sample line
+func (w *Writer) Sample(name string, labels Labels, value float64) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&w.buf, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(formatFloat(value))
+	w.buf.WriteByte('\n')
+}
+
+// Bytes returns the rendered metrics
+func (w *Writer) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+// Handler serves the collector's metrics over HTTP
+func Handler(c *Collector) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var w Writer
+		c.Write(&w)
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		rw.Write(w.Bytes())
+	})
+}
+
+// Serve starts the metrics endpoint on addr in the background. The listener
+// is opened before returning so a busy port is reported immediately.
+func Serve(addr string, c *Collector) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(c))
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go srv.Serve(ln)
+
+	return srv, nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xaelophone/ralph-setup/internal/config"
+)
+
+// alert notifies the user through each configured method. Escape sequences
+// go to stderr so they don't interleave with the renderer's stdout frames;
+// inside tmux they are wrapped in a DCS passthrough so the outer terminal
+// sees them.
+func alert(methods []string, title, body string) tea.Cmd {
+	if len(methods) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		inTmux := os.Getenv("TMUX") != ""
+
+		for _, method := range methods {
+			switch method {
+			case config.AlertBell:
+				os.Stderr.WriteString("\a")
+			case config.AlertOSC9:
+				writeEscape(fmt.Sprintf("\x1b]9;%s: %s\a", title, sanitizeOSC(body)), inTmux)
+			case config.AlertOSC777:
+				writeEscape(fmt.Sprintf("\x1b]777;notify;%s;%s\a", sanitizeOSC(title), sanitizeOSC(body)), inTmux)
+			case config.AlertTmux:
+				if inTmux {
+					exec.Command("tmux", "display-message", fmt.Sprintf("rwatch: %s: %s", title, body)).Run()
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// writeEscape writes a terminal escape sequence, wrapping it for tmux
+func writeEscape(seq string, inTmux bool) {
+	if inTmux {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	os.Stderr.WriteString(seq)
+}
+
+// sanitizeOSC strips characters that would terminate or split an OSC payload
+func sanitizeOSC(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ';':
+			return ','
+		case r < 0x20 || r == 0x7f:
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultDetachKey leaves attach mode unless Options.DetachKey says otherwise
+const DefaultDetachKey = "ctrl+]"
+
+// detachKeyNames are the keys bubbletea reports that can be detach keys:
+// Ctrl and function keys, which don't get in the way of typing. Keys a
+// terminal sends as something else (Ctrl+I is Tab) aren't among them.
+var detachKeyNames = func() map[string]bool {
+	names := map[string]bool{}
+	for k := tea.KeyType(-256); k < 256; k++ {
+		name := k.String()
+		if strings.HasPrefix(name, "ctrl+") || (strings.HasPrefix(name, "f") && len(name) <= 3) {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// ParseDetachKey turns a detach key as users write it ("Ctrl+B", "C-b",
+// "^B", "alt+F12") into bubbletea's name for it, rejecting keys that can't
+// be told apart from typing or that the terminal never reports.
+func ParseDetachKey(key string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(key))
+	alt := false
+	for _, prefix := range []string{"alt+", "alt-", "meta+", "meta-", "m-"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			name, alt = rest, true
+			break
+		}
+	}
+	for _, prefix := range []string{"ctrl-", "control+", "control-", "c-", "^"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			name = "ctrl+" + rest
+			break
+		}
+	}
+
+	if !detachKeyNames[name] {
+		return "", fmt.Errorf("unknown detach key %q (use a Ctrl or function key, e.g. ctrl+] or f12)", key)
+	}
+	if alt {
+		name = "alt+" + name
+	}
+	return name, nil
+}
+
+// handleOutputKey handles keys for the Output view. In legacy mode, a
+// attaches to Claude's terminal so keys can answer its questions.
+func (m Model) handleOutputKey(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	if msg.String() != "a" || m.runner == nil || !m.claudeRunning {
+		return m, nil, false
+	}
+	m.attached = true
+	return m, nil, true
+}
+
+// handleAttachedKey sends every key but the detach key to Claude's PTY
+func (m Model) handleAttachedKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == m.detachKey {
+		m.attached = false
+		return m, nil
+	}
+	if input := encodeKey(msg, m.term.AppCursorKeys(), m.term.BracketedPaste()); input != "" {
+		m.runner.SendInput(input)
+	}
+	return m, nil
+}
+
+// cursorKeys are the final bytes of the arrow, Home and End sequences
+var cursorKeys = map[tea.KeyType]byte{
+	tea.KeyUp: 'A', tea.KeyDown: 'B', tea.KeyRight: 'C', tea.KeyLeft: 'D',
+	tea.KeyHome: 'H', tea.KeyEnd: 'F',
+}
+
+// modifiedCursorKeys are cursor keys with Shift, Ctrl or both, as the final
+// byte and xterm's modifier parameter
+var modifiedCursorKeys = map[tea.KeyType]string{
+	tea.KeyShiftUp: "2A", tea.KeyShiftDown: "2B", tea.KeyShiftRight: "2C", tea.KeyShiftLeft: "2D",
+	tea.KeyShiftHome: "2H", tea.KeyShiftEnd: "2F",
+	tea.KeyCtrlUp: "5A", tea.KeyCtrlDown: "5B", tea.KeyCtrlRight: "5C", tea.KeyCtrlLeft: "5D",
+	tea.KeyCtrlHome: "5H", tea.KeyCtrlEnd: "5F",
+	tea.KeyCtrlShiftUp: "6A", tea.KeyCtrlShiftDown: "6B", tea.KeyCtrlShiftRight: "6C", tea.KeyCtrlShiftLeft: "6D",
+	tea.KeyCtrlShiftHome: "6H", tea.KeyCtrlShiftEnd: "6F",
+}
+
+// specialKeys are the remaining keys with fixed xterm sequences
+var specialKeys = map[tea.KeyType]string{
+	tea.KeySpace:      " ",
+	tea.KeyShiftTab:   "\x1b[Z",
+	tea.KeyInsert:     "\x1b[2~",
+	tea.KeyDelete:     "\x1b[3~",
+	tea.KeyPgUp:       "\x1b[5~",
+	tea.KeyPgDown:     "\x1b[6~",
+	tea.KeyCtrlPgUp:   "\x1b[5;5~",
+	tea.KeyCtrlPgDown: "\x1b[6;5~",
+	tea.KeyF1:         "\x1bOP",
+	tea.KeyF2:         "\x1bOQ",
+	tea.KeyF3:         "\x1bOR",
+	tea.KeyF4:         "\x1bOS",
+	tea.KeyF5:         "\x1b[15~",
+	tea.KeyF6:         "\x1b[17~",
+	tea.KeyF7:         "\x1b[18~",
+	tea.KeyF8:         "\x1b[19~",
+	tea.KeyF9:         "\x1b[20~",
+	tea.KeyF10:        "\x1b[21~",
+	tea.KeyF11:        "\x1b[23~",
+	tea.KeyF12:        "\x1b[24~",
+}
+
+// encodeKey turns a key back into the bytes a terminal would send for it.
+// appCursor selects application cursor keys and bracketed wraps pastes, as
+// the program asked. Keys with no encoding return "".
+func encodeKey(msg tea.KeyMsg, appCursor, bracketed bool) string {
+	var s string
+	switch {
+	case msg.Type == tea.KeyRunes:
+		s = string(msg.Runes)
+		if msg.Paste && bracketed {
+			return "\x1b[200~" + s + "\x1b[201~"
+		}
+	case msg.Type >= 0 && msg.Type < 128:
+		s = string(rune(msg.Type)) // Control characters, Enter, Tab, Backspace, Esc
+	case cursorKeys[msg.Type] != 0:
+		if appCursor {
+			s = "\x1bO" + string(cursorKeys[msg.Type])
+		} else {
+			s = "\x1b[" + string(cursorKeys[msg.Type])
+		}
+	case modifiedCursorKeys[msg.Type] != "":
+		s = "\x1b[1;" + modifiedCursorKeys[msg.Type]
+	default:
+		s = specialKeys[msg.Type]
+	}
+
+	if msg.Alt && s != "" {
+		s = "\x1b" + s
+	}
+	return s
+}
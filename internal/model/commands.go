@@ -1,15 +1,24 @@
 package model
 
 import (
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xaelophone/ralph-setup/internal/orchestrator"
 	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
 	"github.com/xaelophone/ralph-setup/internal/watcher"
 )
 
-// loadTasks loads and parses PRD.md
+// loadTasks loads and parses the PRD files (PRD.md, prd.yaml or prd.json, or
+// the configured epics)
 func (m Model) loadTasks() tea.Cmd {
+	project := m.project
 	return func() tea.Msg {
-		tasks, err := parser.ParsePRD("PRD.md")
+		tasks, err := project.Tasks()
 		if err != nil {
 			// Not an error - PRD might not exist yet
 			return TasksUpdatedMsg{Tasks: []parser.Task{}}
@@ -30,30 +39,83 @@ func (m Model) loadProgress() tea.Cmd {
 	}
 }
 
-// watchFiles starts watching PRD.md and progress.txt for changes
-func (m Model) watchFiles() tea.Cmd {
+// loadHandoff loads and parses HANDOFF.md
+func (m Model) loadHandoff() tea.Cmd {
 	return func() tea.Msg {
-		changes := make(chan string)
-		go watcher.Watch([]string{"PRD.md", "progress.txt"}, changes)
-
-		// This will block and send file change messages
-		// In a real implementation, we'd use a subscription pattern
-		go func() {
-			for file := range changes {
-				// Note: This is a simplified approach
-				// In production, we'd send messages through a channel to the program
-				_ = file
-			}
-		}()
+		handoff, err := parser.ParseHandoff(orchestrator.HandoffFile)
+		if err != nil {
+			// Not an error - HANDOFF.md only exists once something is blocked
+			return HandoffUpdatedMsg{Entries: []parser.HandoffEntry{}}
+		}
+		return HandoffUpdatedMsg{Entries: handoff.Entries}
+	}
+}
+
+// Watch targets; each change is delivered as its own message type
+const (
+	watchPRD      = "prd"
+	watchProgress = "progress"
+	watchHandoff  = "handoff"
+	watchLogs     = "logs"
+	watchGit      = "git"
+)
+
+// watchTargets lists the files the TUI refreshes from
+func (m Model) watchTargets() []watcher.Target {
+	return []watcher.Target{
+		{Name: watchPRD, Patterns: append(slices.Clone(prd.Candidates), m.project.Patterns...)},
+		{Name: watchProgress, Patterns: []string{"progress.txt"}},
+		{Name: watchHandoff, Patterns: []string{orchestrator.HandoffFile}},
+		{Name: watchLogs, Patterns: []string{orchestrator.DefaultConfig().LogDir + "/"}},
+		{Name: watchGit, Patterns: []string{".git/refs/", ".git/HEAD"}},
+	}
+}
 
+// waitForChange delivers the watcher's next event as a typed message. It is
+// re-issued after each one, so changes arrive as a subscription.
+func (m Model) waitForChange() tea.Cmd {
+	if m.watcher == nil {
 		return nil
 	}
+	events := m.watcher.Events()
+	return func() tea.Msg {
+		ev, ok := <-events
+		if !ok {
+			return nil // Stopped
+		}
+		if ev.Err != nil {
+			return WatchErrorMsg{Err: ev.Err}
+		}
+		switch ev.Target {
+		case watchPRD:
+			return PRDChangedMsg{Files: ev.Paths}
+		case watchProgress:
+			return ProgressChangedMsg{}
+		case watchHandoff:
+			return HandoffChangedMsg{}
+		case watchLogs:
+			return LogsChangedMsg{Files: ev.Paths}
+		default:
+			return GitChangedMsg{}
+		}
+	}
 }
 
+// maxGitCommits is how many commits the Git view lists
+const maxGitCommits = 50
+
 // loadGitCommits loads recent git commits
 func (m Model) loadGitCommits() tea.Cmd {
 	return func() tea.Msg {
-		// TODO: Implement git log parsing
-		return GitUpdatedMsg{Commits: []string{}}
+		out, err := exec.Command("git", "log", "--oneline", "--no-decorate", "-n", strconv.Itoa(maxGitCommits)).Output()
+		if err != nil {
+			// Not an error - the project might not be a repository yet
+			return GitUpdatedMsg{Commits: []string{}}
+		}
+		commits := strings.Split(strings.TrimSpace(string(out)), "\n")
+		if len(commits) == 1 && commits[0] == "" {
+			commits = []string{}
+		}
+		return GitUpdatedMsg{Commits: commits}
 	}
 }
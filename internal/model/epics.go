@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/prd"
+)
+
+// epic is the progress of one PRD file in a multi-file project
+type epic struct {
+	name  string
+	file  string
+	done  int
+	total int
+}
+
+// epics summarises the top-level tasks of each PRD file, in project order.
+// It is empty unless the project is configured with its own PRD files.
+func (m Model) epics() []epic {
+	if !m.project.Multi() {
+		return nil
+	}
+
+	var epics []epic
+	for _, t := range m.tasks {
+		if t.Indent > 0 {
+			continue
+		}
+		if len(epics) == 0 || epics[len(epics)-1].file != t.File {
+			epics = append(epics, epic{name: prd.EpicName(t.File), file: t.File})
+		}
+		e := &epics[len(epics)-1]
+		e.total++
+		if t.Complete {
+			e.done++
+		}
+	}
+	return epics
+}
+
+// renderEpics renders a progress bar per epic for the sidebar
+func (m Model) renderEpics(width int) string {
+	var sb strings.Builder
+	for _, e := range m.epics() {
+		count := " " + itoa(e.done) + "/" + itoa(e.total)
+		style := m.theme.SidebarItem
+		if e.total > 0 && e.done == e.total {
+			style = m.theme.Success
+		}
+		sb.WriteString("  " + style.Render(wrapText(e.name, width-6)) + "\n")
+		sb.WriteString("  " + progressBar(width-6-len(count), e.done, e.total) + m.theme.Muted.Render(count) + "\n")
+	}
+	return sb.String()
+}
+
+// progressBar draws done/total as a bar width cells wide
+func progressBar(width, done, total int) string {
+	if width <= 0 {
+		return ""
+	}
+	filled := 0
+	if total > 0 {
+		filled = done * width / total
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
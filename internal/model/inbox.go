@@ -0,0 +1,206 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xaelophone/ralph-setup/internal/orchestrator"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
+	"github.com/xaelophone/ralph-setup/internal/prompt"
+)
+
+// inboxKind distinguishes the two sources of inbox items
+type inboxKind int
+
+const (
+	inboxHuman   inboxKind = iota // Open 🧑 task in PRD.md
+	inboxBlocked                  // Blocked entry in HANDOFF.md
+)
+
+// inboxItem is something waiting for the human
+type inboxItem struct {
+	kind  inboxKind
+	title string
+	task  parser.Task         // For human tasks
+	entry parser.HandoffEntry // For blocked entries
+}
+
+// inboxItems lists open human tasks followed by blocked tasks
+func (m Model) inboxItems() []inboxItem {
+	var items []inboxItem
+	for _, t := range m.tasks {
+		if !t.Complete && strings.Contains(t.Title, prd.MarkerHuman) {
+			items = append(items, inboxItem{kind: inboxHuman, title: prd.NormalizeTitle(t.Title), task: t})
+		}
+	}
+	for _, e := range m.handoff {
+		items = append(items, inboxItem{kind: inboxBlocked, title: e.Task, entry: e})
+	}
+	return items
+}
+
+// selectedInboxItem returns the item under the cursor
+func (m Model) selectedInboxItem() (inboxItem, bool) {
+	items := m.inboxItems()
+	if len(items) == 0 {
+		return inboxItem{}, false
+	}
+	return items[min(m.inboxCursor, len(items)-1)], true
+}
+
+// handleInboxKey handles keys in the inbox view, reporting whether the key was used
+func (m Model) handleInboxKey(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	items := m.inboxItems()
+
+	switch msg.String() {
+	case "j", "down":
+		if m.inboxCursor < len(items)-1 {
+			m.inboxCursor++
+		}
+		return m, nil, true
+
+	case "k", "up":
+		if m.inboxCursor > 0 {
+			m.inboxCursor--
+		}
+		return m, nil, true
+
+	case "x", "enter":
+		item, ok := m.selectedInboxItem()
+		if !ok || item.kind != inboxHuman {
+			return m, nil, true
+		}
+		return m, m.completeHumanTask(item.task), true
+
+	case "r":
+		item, ok := m.selectedInboxItem()
+		if !ok || item.kind != inboxBlocked {
+			return m, nil, true
+		}
+		return m, m.retryBlockedTask(item.entry.Task), true
+
+	case "n":
+		item, ok := m.selectedInboxItem()
+		if !ok {
+			return m, nil, true
+		}
+		m, cmd := m.startInput(inputNote, item.title, "")
+		return m, cmd, true
+	}
+
+	return m, nil, false
+}
+
+// renderInbox renders human tasks and blocked entries
+func (m Model) renderInbox(width int) string {
+	items := m.inboxItems()
+
+	var sb strings.Builder
+	if len(items) == 0 {
+		sb.WriteString(m.theme.Muted.Render("Nothing needs you right now.\n🧑 tasks from PRD.md and blocked tasks from HANDOFF.md appear here.") + "\n")
+	}
+
+	cursor := min(m.inboxCursor, len(items)-1)
+	lastKind := inboxKind(-1)
+	for i, item := range items {
+		if item.kind != lastKind {
+			if lastKind != -1 {
+				sb.WriteString("\n")
+			}
+			if item.kind == inboxHuman {
+				sb.WriteString(m.theme.SidebarHeader.Render("🧑 Human tasks (PRD.md)") + "\n")
+			} else {
+				sb.WriteString(m.theme.SidebarHeader.Render("⛔ Blocked (HANDOFF.md)") + "\n")
+			}
+			lastKind = item.kind
+		}
+
+		prefix := "  "
+		style := m.theme.TaskPending
+		if i == cursor {
+			prefix = "► "
+			style = m.theme.TaskCurrent
+		}
+		sb.WriteString(style.Render(prefix+wrapText(item.title, width-4)) + "\n")
+
+		if item.kind == inboxBlocked && i == cursor {
+			if item.entry.Reason != "" {
+				reason := strings.SplitN(item.entry.Reason, "\n", 2)[0]
+				sb.WriteString(m.theme.Muted.Render("    "+wrapText(reason, width-6)) + "\n")
+			}
+			if item.entry.Count > 1 {
+				sb.WriteString(m.theme.Muted.Render(fmt.Sprintf("    blocked %d times", item.entry.Count)) + "\n")
+			}
+			if item.entry.LogFile != "" {
+				sb.WriteString(m.theme.Muted.Render("    log: "+item.entry.LogFile) + "\n")
+			}
+		}
+	}
+
+	sb.WriteString("\n")
+	if m.input.Focused() {
+		sb.WriteString(m.renderInput(width))
+	} else {
+		sb.WriteString(m.theme.Muted.Render("[x] mark done  [r] retry as 🤖  [n] add note  [j/k] move") + "\n")
+	}
+	if m.editStatus != "" {
+		sb.WriteString(m.editStatus + "\n")
+	}
+
+	return sb.String()
+}
+
+// completeHumanTask ticks a human task in PRD.md
+func (m Model) completeHumanTask(task parser.Task) tea.Cmd {
+	title := prd.NormalizeTitle(task.Title)
+	return m.editPRD(task, "✓ Marked done: "+title, "", func(e prd.Editor) error {
+		i, err := e.Locate(task.Title, task.Line)
+		if err != nil {
+			return err
+		}
+		e.SetComplete(i, true)
+		return nil
+	})
+}
+
+// retryBlockedTask hands a blocked task back to the agent: the ⛔ note and
+// 🧑 marker are replaced with 🤖 in PRD.md and the HANDOFF.md entry is
+// removed. The orchestrator resets its attempt count when it picks the task.
+func (m Model) retryBlockedTask(task string) tea.Cmd {
+	source := m.project.Source(parser.Task{})
+	for _, t := range m.tasks {
+		if prd.NormalizeTitle(t.Title) == task {
+			source = m.project.Source(t)
+			break
+		}
+	}
+	return func() tea.Msg {
+		err := source.Edit(func(e prd.Editor) error {
+			i, err := e.Locate(task, 0)
+			if err != nil {
+				return err
+			}
+			e.Unblock(i)
+			return nil
+		})
+		if err == nil {
+			err = orchestrator.RemoveHandoff(task)
+		}
+		if err != nil {
+			return EditResultMsg{Err: err}
+		}
+		return EditResultMsg{Status: "🤖 Queued for retry: " + task}
+	}
+}
+
+// addNote queues a note for the next prompt
+func addNote(target, note string) tea.Cmd {
+	return func() tea.Msg {
+		if err := prompt.AddNote(target, note); err != nil {
+			return EditResultMsg{Err: err}
+		}
+		return EditResultMsg{Status: "✎ Note added for the next prompt"}
+	}
+}
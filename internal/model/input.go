@@ -0,0 +1,98 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// inputMode is what the text input is being used for
+type inputMode int
+
+const (
+	inputNote     inputMode = iota // Note for the next prompt
+	inputAddTask                   // Title of a new task
+	inputEditTask                  // New title of an existing task
+)
+
+// inputLabels describe each input mode above the text field
+var inputLabels = map[inputMode]string{
+	inputNote:     "Note on: ",
+	inputAddTask:  "New 🤖 task after: ",
+	inputEditTask: "Rename: ",
+}
+
+// inputPlaceholders hint at what to type
+var inputPlaceholders = map[inputMode]string{
+	inputNote:     "Note for the agent's next prompt",
+	inputAddTask:  "Task title, optionally with P0-P3 and #tags",
+	inputEditTask: "Task title, optionally with P0-P3 and #tags",
+}
+
+// newTextInput creates the single-line input shared by the editable views
+func newTextInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "✎ "
+	ti.CharLimit = 500
+	return ti
+}
+
+// startInput focuses the text input for mode. target is the task the input
+// refers to and value the initial text. Task edits keep the selected task, so
+// PRD.md reloading while the user types doesn't change what they apply to.
+func (m Model) startInput(mode inputMode, target, value string) (Model, tea.Cmd) {
+	m.inputMode = mode
+	m.inputTarget = target
+	m.inputTask = nil
+	if task := m.selectedTask(); task != nil && mode != inputNote {
+		selected := *task
+		m.inputTask = &selected
+	}
+	m.input.Placeholder = inputPlaceholders[mode]
+	m.input.Reset()
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m, m.input.Focus()
+}
+
+// handleInputKey feeds keys to the text input while it is focused
+func (m Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.input.Blur()
+		return m, nil
+
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		m.input.Blur()
+		if value == "" {
+			return m, nil
+		}
+		switch m.inputMode {
+		case inputNote:
+			return m, addNote(m.inputTarget, value)
+		case inputAddTask:
+			return m, m.addTask(m.inputTask, value)
+		case inputEditTask:
+			return m, m.renameTask(m.inputTask, value)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// renderInput renders the focused text input with its label and keys
+func (m Model) renderInput(width int) string {
+	var sb strings.Builder
+	label := inputLabels[m.inputMode]
+	if m.inputTarget != "" {
+		sb.WriteString(m.theme.Muted.Render(label+wrapText(m.inputTarget, width-len(label))) + "\n")
+	}
+	sb.WriteString(m.input.View() + "\n")
+	sb.WriteString(m.theme.Muted.Render("[Enter] save  [Esc] cancel") + "\n")
+	return sb.String()
+}
@@ -26,9 +26,28 @@ type ProgressUpdatedMsg struct {
 	Entries []parser.ProgressEntry
 }
 
-// FileChangedMsg indicates a watched file has changed
-type FileChangedMsg struct {
-	File string
+// PRDChangedMsg indicates a PRD file was written, replaced or removed
+type PRDChangedMsg struct {
+	Files []string
+}
+
+// ProgressChangedMsg indicates progress.txt changed
+type ProgressChangedMsg struct{}
+
+// HandoffChangedMsg indicates HANDOFF.md changed
+type HandoffChangedMsg struct{}
+
+// LogsChangedMsg indicates iteration logs were written
+type LogsChangedMsg struct {
+	Files []string
+}
+
+// GitChangedMsg indicates a commit, checkout or other ref change
+type GitChangedMsg struct{}
+
+// WatchErrorMsg reports a file watching failure
+type WatchErrorMsg struct {
+	Err error
 }
 
 // GitUpdatedMsg indicates git commits have been refreshed
@@ -40,3 +59,23 @@ type GitUpdatedMsg struct {
 type ErrorMsg struct {
 	Error error
 }
+
+// HandoffUpdatedMsg indicates HANDOFF.md has been parsed
+type HandoffUpdatedMsg struct {
+	Entries []parser.HandoffEntry
+}
+
+// EditResultMsg reports the outcome of an edit made from the TUI
+type EditResultMsg struct {
+	Status string
+	Err    error
+	Select string // Task to put the cursor on once PRD.md is reloaded
+}
+
+// ContinuedMsg reports the legacy session was moved on to the next task, or
+// why it wasn't
+type ContinuedMsg struct {
+	Iteration int
+	Done      bool // No task left to run
+	Err       error
+}
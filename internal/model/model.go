@@ -1,18 +1,24 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/xaelophone/ralph-setup/internal/config"
 	"github.com/xaelophone/ralph-setup/internal/orchestrator"
 	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
 	"github.com/xaelophone/ralph-setup/internal/runner"
 	"github.com/xaelophone/ralph-setup/internal/theme"
+	"github.com/xaelophone/ralph-setup/internal/vterm"
+	"github.com/xaelophone/ralph-setup/internal/watcher"
 )
 
 // Re-export runner message types so main.go doesn't need to import runner
@@ -39,6 +45,7 @@ const (
 	ViewSubagents
 	ViewProgress
 	ViewGit
+	ViewInbox
 )
 
 // Options for creating a new model
@@ -46,6 +53,11 @@ type Options struct {
 	MonitorOnly    bool
 	OrchestratorMode bool
 	ClaudeArgs     []string
+	Alerts         config.TUINotificationConfig
+	PRD            prd.Project // PRD files shown in the Tasks view
+	DetachKey      string      // Key that leaves attach mode; default DefaultDetachKey
+	AutoContinue   bool        // Send /clear and the next prompt when the legacy CLI completes a task
+	NextPrompt     func(iteration int) (text string, ok bool, err error) // Prompt for the next task in legacy mode
 }
 
 // Model is the main Bubbletea model
@@ -54,6 +66,8 @@ type Model struct {
 	monitorOnly      bool
 	orchestratorMode bool
 	claudeArgs       []string
+	alerts           config.TUINotificationConfig
+	project          prd.Project
 
 	// Dimensions
 	width  int
@@ -71,6 +85,17 @@ type Model struct {
 	progressLog   []parser.ProgressEntry
 	gitCommits    []string
 	subagents     []orchestrator.SubagentTrace
+	handoff       []parser.HandoffEntry
+
+	// Editing
+	taskCursor  int
+	inboxCursor int
+	editStatus  string
+	selectTitle string // Task to select once PRD.md is reloaded
+	input       textinput.Model
+	inputMode   inputMode
+	inputTarget string
+	inputTask   *parser.Task // Task being renamed or added after, as it was when editing started
 
 	// State
 	claudeRunning   bool
@@ -89,8 +114,25 @@ type Model struct {
 	// Runner reference (for cleanup) - legacy mode
 	runner *runner.Runner
 
+	// Emulated screen Claude's PTY output is drawn on - legacy mode
+	term       *vterm.Terminal
+	termOutput bool   // Claude has written to the screen
+	attached   bool   // Keys go to Claude's PTY
+	detachKey  string // Key that ends attach mode
+
+	// Completion tokens in the legacy CLI's output
+	legacyStatus string // Rendered status bar indicator
+	autoContinue bool
+	nextPrompt   func(iteration int) (string, bool, error)
+
 	// Orchestrator reference - new mode
 	orchestrator *orchestrator.Orchestrator
+
+	// File watching
+	watcher   *watcher.Watcher
+	watchErr  error
+	stopWatch context.CancelFunc
+	seenLogs  map[string]bool // Iteration logs already announced
 }
 
 // New creates a new model
@@ -98,23 +140,44 @@ func New(opts Options) *Model {
 	vp := viewport.New(80, 20)
 	vp.Style = lipgloss.NewStyle()
 
-	return &Model{
+	m := &Model{
 		monitorOnly:      opts.MonitorOnly,
 		orchestratorMode: opts.OrchestratorMode,
 		claudeArgs:       opts.ClaudeArgs,
+		alerts:           opts.Alerts,
+		project:          opts.PRD,
 		activeView:       ViewOutput,
 		outputViewport:   vp,
 		tasks:            []parser.Task{},
 		progressLog:      []parser.ProgressEntry{},
 		gitCommits:       []string{},
 		subagents:        []orchestrator.SubagentTrace{},
+		handoff:          []parser.HandoffEntry{},
+		seenLogs:         map[string]bool{},
+		input:            newTextInput(),
 		startTime:        time.Now(),
 		projectName:      getProjectName(),
 		theme:            theme.Default(),
+		detachKey:        opts.DetachKey,
+		autoContinue:     opts.AutoContinue,
+		nextPrompt:       opts.NextPrompt,
+	}
+	if m.detachKey == "" {
+		m.detachKey = DefaultDetachKey
+	}
+
+	if !opts.MonitorOnly && !opts.OrchestratorMode {
+		m.term = vterm.New(80, 24)
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopWatch = cancel
+	m.watcher, m.watchErr = watcher.New(ctx, watcher.DefaultDebounce, m.watchTargets()...)
+	return m
 }
 
-// SetRunner sets the Claude runner reference (legacy mode)
+// SetRunner sets the Claude runner reference (legacy mode). Call it before
+// the program runs so window sizes reach the runner's PTY.
 func (m *Model) SetRunner(r *runner.Runner) {
 	m.runner = r
 }
@@ -129,10 +192,22 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.loadTasks(),
 		m.loadProgress(),
-		m.watchFiles(),
+		m.loadHandoff(),
+		m.loadGitCommits(),
+		m.waitForChange(),
+		m.reportWatchError(),
 	)
 }
 
+// reportWatchError shows why file watching couldn't start, if it didn't
+func (m Model) reportWatchError() tea.Cmd {
+	if m.watchErr == nil {
+		return nil
+	}
+	err := m.watchErr
+	return func() tea.Msg { return WatchErrorMsg{Err: err} }
+}
+
 // Update handles messages
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
@@ -145,6 +220,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.updateViewportSize()
+		m.resizeTerminal()
 
 	// Legacy runner messages
 	case OutputMsg:
@@ -153,6 +229,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.outputViewport.GotoBottom()
 
 	case runner.OutputMsg:
+		if m.term != nil {
+			m.writeTerminal(msg.Content)
+			break
+		}
 		m.claudeOutput += msg.Content
 		m.outputViewport.SetContent(m.claudeOutput)
 		m.outputViewport.GotoBottom()
@@ -168,6 +248,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case runner.StoppedMsg:
 		m.claudeRunning = false
+		m.attached = false
+
+	case runner.CompletionMsg:
+		cmds = append(cmds, m.handleCompletion(msg))
+
+	case ContinuedMsg:
+		cmds = append(cmds, m.handleContinued(msg))
 
 	// Orchestrator messages
 	case orchestrator.OutputMsg:
@@ -191,8 +278,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case orchestrator.CompletionMsg:
 		m.lastCompletion = fmt.Sprintf("[%s] %s", msg.Status, msg.Task)
+		if msg.Status == orchestrator.IterationStatusBlocked {
+			cmds = append(cmds, alert(m.alerts.Blocked, "Task blocked", msg.Task))
+		}
 		cmds = append(cmds, m.loadTasks())
 		cmds = append(cmds, m.loadProgress())
+		cmds = append(cmds, m.loadHandoff())
 
 	case orchestrator.SessionMsg:
 		if msg.Session != nil {
@@ -206,25 +297,71 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case orchestrator.StoppedMsg:
 		m.claudeRunning = false
+		cmds = append(cmds, alert(m.alerts.Stopped, "Stopped", msg.Reason))
 
 	case orchestrator.ErrorMsg:
 		m.claudeOutput += fmt.Sprintf("\n[ERROR] %v\n", msg.Error)
 		m.outputViewport.SetContent(m.claudeOutput)
 		m.outputViewport.GotoBottom()
+		cmds = append(cmds, alert(m.alerts.Error, "Error", fmt.Sprint(msg.Error)))
 
 	// File watching messages
 	case TasksUpdatedMsg:
 		m.tasks = msg.Tasks
+		if m.selectTitle != "" {
+			m.selectTask(m.selectTitle)
+			m.selectTitle = ""
+		}
 
 	case ProgressUpdatedMsg:
 		m.progressLog = msg.Entries
 
-	case FileChangedMsg:
-		switch msg.File {
-		case "PRD.md":
-			cmds = append(cmds, m.loadTasks())
-		case "progress.txt":
-			cmds = append(cmds, m.loadProgress())
+	case HandoffUpdatedMsg:
+		m.handoff = msg.Entries
+
+	case EditResultMsg:
+		if msg.Err != nil {
+			m.editStatus = m.theme.Error.Render("✗ " + msg.Err.Error())
+		} else {
+			m.editStatus = m.theme.Success.Render(msg.Status)
+			m.selectTitle = msg.Select
+		}
+		cmds = append(cmds, m.loadTasks(), m.loadHandoff())
+
+	case GitUpdatedMsg:
+		m.gitCommits = msg.Commits
+
+	// File watching: reload only what changed, then wait for the next change
+	case PRDChangedMsg:
+		cmds = append(cmds, m.loadTasks(), m.waitForChange())
+
+	case ProgressChangedMsg:
+		cmds = append(cmds, m.loadProgress(), m.waitForChange())
+
+	case HandoffChangedMsg:
+		cmds = append(cmds, m.loadHandoff(), m.waitForChange())
+
+	case GitChangedMsg:
+		cmds = append(cmds, m.loadGitCommits(), m.waitForChange())
+
+	case LogsChangedMsg:
+		// Announce new iterations when there's no runner output to show
+		for _, file := range msg.Files {
+			if m.monitorOnly && strings.HasSuffix(file, ".log") && !m.seenLogs[file] {
+				m.seenLogs[file] = true
+				m.claudeOutput += "[log] " + file + "\n"
+				m.outputViewport.SetContent(m.claudeOutput)
+				m.outputViewport.GotoBottom()
+			}
+		}
+		cmds = append(cmds, m.waitForChange())
+
+	case WatchErrorMsg:
+		m.claudeOutput += fmt.Sprintf("[watch] %v\n", msg.Err)
+		m.outputViewport.SetContent(m.claudeOutput)
+		m.outputViewport.GotoBottom()
+		if m.watcher != nil {
+			cmds = append(cmds, m.waitForChange())
 		}
 	}
 
@@ -233,6 +370,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.outputViewport, cmd = m.outputViewport.Update(msg)
 	cmds = append(cmds, cmd)
 
+	// Keep the text input's cursor blinking
+	if m.input.Focused() {
+		m.input, cmd = m.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -256,12 +399,22 @@ func (m *Model) updateSubagent(trace orchestrator.SubagentTrace) {
 
 // handleKeyPress handles keyboard input
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Text being typed gets every key
+	if m.input.Focused() {
+		return m.handleInputKey(msg)
+	}
+	// So does Claude, when attached
+	if m.attached {
+		return m.handleAttachedKey(msg)
+	}
+
 	// Global keys
 	switch msg.String() {
 	case "q", "ctrl+c":
 		if m.runner != nil {
 			m.runner.Stop()
 		}
+		m.stopWatch()
 		return m, tea.Quit
 
 	case "?":
@@ -287,6 +440,9 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "5":
 		m.activeView = ViewGit
 		return m, nil
+	case "6":
+		m.activeView = ViewInbox
+		return m, nil
 
 	case "esc":
 		m.showHelp = false
@@ -295,6 +451,21 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// View-specific keys when not in sidebar
+	if !m.sidebarFocus && m.activeView == ViewTasks {
+		if m, cmd, ok := m.handleTaskKey(msg); ok {
+			return m, cmd
+		}
+	}
+	if !m.sidebarFocus && m.activeView == ViewOutput {
+		if m, cmd, ok := m.handleOutputKey(msg); ok {
+			return m, cmd
+		}
+	}
+	if !m.sidebarFocus && m.activeView == ViewInbox {
+		if m, cmd, ok := m.handleInboxKey(msg); ok {
+			return m, cmd
+		}
+	}
 	if !m.sidebarFocus {
 		switch msg.String() {
 		case "j", "down":
@@ -380,6 +551,11 @@ func (m Model) renderStatusBar() string {
 		)
 	}
 
+	// Completion token seen in legacy mode
+	if m.legacyStatus != "" {
+		iterInfo += m.theme.Muted.Render(" │ ") + m.legacyStatus
+	}
+
 	left := lipgloss.JoinHorizontal(
 		lipgloss.Center,
 		m.theme.Title.Render(" rwatch v2.0.0 "),
@@ -425,6 +601,7 @@ func (m Model) renderSidebar(width, height int) string {
 		{ViewSubagents, "Subagents", len(m.subagents)},
 		{ViewProgress, "Progress", len(m.progressLog)},
 		{ViewGit, "Git", len(m.gitCommits)},
+		{ViewInbox, "Inbox", len(m.inboxItems())},
 	}
 
 	var nav strings.Builder
@@ -446,6 +623,14 @@ func (m Model) renderSidebar(width, height int) string {
 		nav.WriteString(prefix + labelStyle.Render(label) + "\n")
 	}
 
+	// Per-epic progress when the project has several PRD files
+	if epics := m.renderEpics(width); epics != "" {
+		nav.WriteString("\n")
+		nav.WriteString(m.theme.SidebarHeader.Render("Epics") + "\n")
+		nav.WriteString(m.theme.Divider.Render(strings.Repeat("─", width-4)) + "\n")
+		nav.WriteString(epics)
+	}
+
 	// Current task section
 	nav.WriteString("\n")
 	nav.WriteString(m.theme.SidebarHeader.Render("Current Task") + "\n")
@@ -479,7 +664,9 @@ func (m Model) renderMain(width, height int) string {
 	switch m.activeView {
 	case ViewOutput:
 		title = "CLAUDE OUTPUT"
-		if len(m.claudeOutput) == 0 {
+		if m.termOutput {
+			content = m.term.Render(true)
+		} else if len(m.claudeOutput) == 0 {
 			if m.monitorOnly {
 				content = m.theme.Muted.Render("Monitor mode - Claude output from other terminal will not appear here")
 			} else if !m.claudeRunning {
@@ -493,7 +680,7 @@ func (m Model) renderMain(width, height int) string {
 
 	case ViewTasks:
 		title = "TASKS"
-		content = m.renderTaskList(width - 4)
+		content = m.renderTaskList(width-4, height-6)
 
 	case ViewSubagents:
 		title = "SUBAGENT ACTIVITY"
@@ -506,13 +693,23 @@ func (m Model) renderMain(width, height int) string {
 	case ViewGit:
 		title = "GIT COMMITS"
 		content = m.renderGitView(width - 4)
+
+	case ViewInbox:
+		title = "INBOX"
+		content = m.renderInbox(width - 4)
 	}
 
 	titleBar := m.theme.MainTitle.Render(" " + title + " ")
 	titleBar = lipgloss.PlaceHorizontal(width, lipgloss.Center, titleBar)
 
 	footer := ""
-	if m.activeView == ViewOutput {
+	if m.activeView == ViewOutput && m.attached {
+		footer = m.theme.StatusRunning.Render("ATTACHED - keys go to Claude") + m.theme.Muted.Render("  ["+m.detachKey+"] detach")
+	} else if m.activeView == ViewOutput && m.termOutput {
+		if m.claudeRunning {
+			footer = m.theme.Muted.Render("[a] attach to answer Claude")
+		}
+	} else if m.activeView == ViewOutput {
 		if len(m.claudeOutput) > 0 {
 			footer = m.theme.Muted.Render("(auto-scrolling) [Esc] pause  [j/k] scroll")
 		}
@@ -532,24 +729,45 @@ func (m Model) renderMain(width, height int) string {
 	)
 }
 
-// renderTaskList renders the task list from PRD.md
-func (m Model) renderTaskList(width int) string {
+// renderTaskList renders the task list from PRD.md in height lines. Long
+// lists are windowed around the cursor, and the input or key help stays at
+// the bottom.
+func (m Model) renderTaskList(width, height int) string {
 	if len(m.tasks) == 0 {
-		return m.theme.Muted.Render("No PRD.md found or no tasks defined.\nRun 'setup-ralph' and let Claude create a PRD.")
+		empty := m.theme.Muted.Render("No PRD.md found or no tasks defined.\nRun 'setup-ralph' and let Claude create a PRD.")
+		if m.input.Focused() {
+			return empty + "\n\n" + m.renderInput(width)
+		}
+		return empty + "\n\n" + m.theme.Muted.Render("[a] add task")
 	}
 
-	var sb strings.Builder
 	completed := 0
 	for _, t := range m.tasks {
 		if t.Complete {
 			completed++
 		}
 	}
-
-	sb.WriteString(m.theme.Muted.Render("(" + itoa(completed) + "/" + itoa(len(m.tasks)) + " complete)") + "\n\n")
+	header := m.theme.Muted.Render("(" + itoa(completed) + "/" + itoa(len(m.tasks)) + " complete)")
 
 	current := m.getCurrentTask()
-	for _, task := range m.tasks {
+	cursor := min(m.taskCursor, len(m.tasks)-1)
+	cursorLine := 0
+	epics := m.epics()
+	var lines []string
+	for i, task := range m.tasks {
+		// Epic header before each PRD file's tasks
+		if len(epics) > 0 && (i == 0 || task.File != m.tasks[i-1].File) {
+			for _, e := range epics {
+				if e.file == task.File {
+					if i > 0 {
+						lines = append(lines, "")
+					}
+					lines = append(lines, m.theme.SidebarHeader.Render(e.name)+" "+m.theme.Muted.Render(itoa(e.done)+"/"+itoa(e.total)))
+					break
+				}
+			}
+		}
+
 		icon := "◌"
 		style := m.theme.TaskPending
 		suffix := ""
@@ -563,11 +781,57 @@ func (m Model) renderTaskList(width int) string {
 			suffix = "  ← CURRENT"
 		}
 
-		line := icon + " " + wrapText(task.Title, width-4) + suffix
-		sb.WriteString(style.Render(line) + "\n")
+		prefix := "  "
+		if i == cursor {
+			prefix = "► "
+			cursorLine = len(lines)
+		}
+		badges := m.taskBadges(task)
+		text, _ := parser.SplitMarkers(task.Title)
+		indent := strings.Repeat(" ", task.Indent)
+		line := indent + icon + " " + wrapText(text, max(10, width-6-task.Indent-lipgloss.Width(badges+suffix)))
+		lines = append(lines, prefix+style.Render(line)+badges+style.Render(suffix))
 	}
 
-	return sb.String()
+	var footer string
+	if m.input.Focused() {
+		footer = m.renderInput(width)
+	} else {
+		footer = m.theme.Muted.Render("[a] add  [e] edit  [o] 🤖/🧑  [x] done  [J/K] move  [j/k] select") + "\n"
+	}
+	if m.editStatus != "" {
+		footer += m.editStatus + "\n"
+	}
+	footer = strings.TrimSuffix(footer, "\n")
+
+	// Header and blank line above the list, blank line and footer below it
+	rows := max(1, height-3-strings.Count(footer, "\n")-1)
+	lines = windowLines(lines, cursorLine, rows, m.theme.Muted)
+	for len(lines) < rows {
+		lines = append(lines, "")
+	}
+
+	return header + "\n\n" + strings.Join(lines, "\n") + "\n\n" + footer
+}
+
+// windowLines returns the rows lines of lines around line at, marking the
+// lines cut off above and below when there is room
+func windowLines(lines []string, at, rows int, muted lipgloss.Style) []string {
+	if len(lines) <= rows {
+		return lines
+	}
+	start := min(max(at-rows/2, 0), len(lines)-rows)
+	window := append([]string(nil), lines[start:start+rows]...)
+	if rows < 3 {
+		return window
+	}
+	if start > 0 {
+		window[0] = muted.Render("  ↑ " + itoa(start+1) + " more")
+	}
+	if below := len(lines) - start - rows; below > 0 {
+		window[rows-1] = muted.Render("  ↓ " + itoa(below+1) + " more")
+	}
+	return window
 }
 
 // renderProgressLog renders the progress.txt entries
@@ -682,7 +946,7 @@ func (m Model) renderHelp() string {
  │  Navigation                             │
  │  ─────────────────────────────────────  │
  │  Tab        Toggle sidebar focus        │
- │  1-5        Switch views                │
+ │  1-6        Switch views                │
  │  j/↓        Scroll down                 │
  │  k/↑        Scroll up                   │
  │  g          Go to top                   │
@@ -702,6 +966,25 @@ func (m Model) renderHelp() string {
  │  3  Subagents - Tool call activity      │
  │  4  Progress  - progress.txt log        │
  │  5  Git       - Recent commits          │
+ │  6  Inbox     - Tasks waiting on you    │
+ │                                         │
+ │  Output (--legacy)                      │
+ │  ─────────────────────────────────────  │
+ │  a          Attach: keys go to Claude   │
+ │  Ctrl+]     Detach (--detach-key)       │
+ │                                         │
+ │  Tasks                                  │
+ │  ─────────────────────────────────────  │
+ │  a / e      Add / edit task             │
+ │  o          Toggle 🤖/🧑 owner          │
+ │  x/Space    Toggle complete             │
+ │  J/K        Move down/up (priority)     │
+ │                                         │
+ │  Inbox                                  │
+ │  ─────────────────────────────────────  │
+ │  x/Enter    Mark 🧑 task done           │
+ │  r          Retry ⛔ task as 🤖         │
+ │  n          Add note to next prompt     │
  │                                         │
  │  Orchestrator Mode                      │
  │  ─────────────────────────────────────  │
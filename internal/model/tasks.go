@@ -0,0 +1,194 @@
+package model
+
+import (
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
+)
+
+// selectedTask returns the task under the cursor in the Tasks view
+func (m Model) selectedTask() *parser.Task {
+	if len(m.tasks) == 0 {
+		return nil
+	}
+	return &m.tasks[min(m.taskCursor, len(m.tasks)-1)]
+}
+
+// handleTaskKey handles editing keys in the Tasks view, reporting whether
+// the key was used
+func (m Model) handleTaskKey(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	task := m.selectedTask()
+
+	switch msg.String() {
+	case "j", "down":
+		if m.taskCursor < len(m.tasks)-1 {
+			m.taskCursor++
+		}
+		return m, nil, true
+
+	case "k", "up":
+		if m.taskCursor > 0 {
+			m.taskCursor--
+		}
+		return m, nil, true
+
+	case "a":
+		target := ""
+		if task != nil {
+			target = prd.NormalizeTitle(task.Title)
+		}
+		m, cmd := m.startInput(inputAddTask, target, "")
+		return m, cmd, true
+	}
+
+	if task == nil {
+		return m, nil, false
+	}
+
+	switch msg.String() {
+	case "e":
+		m, cmd := m.startInput(inputEditTask, prd.NormalizeTitle(task.Title), prd.EditableTitle(task.Title))
+		return m, cmd, true
+
+	case "x", " ":
+		return m, m.toggleTaskComplete(*task), true
+
+	case "o":
+		return m, m.toggleTaskOwner(*task), true
+
+	case "K", "shift+up":
+		return m, m.moveTask(*task, -1), true
+
+	case "J", "shift+down":
+		return m, m.moveTask(*task, 1), true
+	}
+
+	return m, nil, false
+}
+
+// selectTask puts the task cursor on the task titled title
+func (m *Model) selectTask(title string) {
+	for i, t := range m.tasks {
+		if prd.NormalizeTitle(t.Title) == title {
+			m.taskCursor = i
+			return
+		}
+	}
+}
+
+// addTask adds a 🤖 task after the selected one, in the same PRD file, or at
+// the end of the first PRD file when there is none
+func (m Model) addTask(after *parser.Task, title string) tea.Cmd {
+	name := prd.NormalizeTitle(title)
+	var in parser.Task
+	if after != nil {
+		in = *after
+	}
+	return m.editPRD(in, "+ Added: "+name, name, func(e prd.Editor) error {
+		i := -1
+		if after != nil {
+			var err error
+			if i, err = e.Locate(after.Title, after.Line); err != nil {
+				return err
+			}
+		}
+		e.Add(i, title, prd.MarkerAI)
+		return nil
+	})
+}
+
+// renameTask changes a task's title, with any priority and tag markers
+func (m Model) renameTask(task *parser.Task, title string) tea.Cmd {
+	if task == nil {
+		return func() tea.Msg {
+			return EditResultMsg{Err: errors.New("no task selected to rename")}
+		}
+	}
+	name := prd.NormalizeTitle(title)
+	return m.editPRD(*task, "✎ Renamed: "+name, name, func(e prd.Editor) error {
+		i, err := e.Locate(task.Title, task.Line)
+		if err != nil {
+			return err
+		}
+		e.SetTitle(i, title)
+		return nil
+	})
+}
+
+// toggleTaskComplete ticks or unticks a task
+func (m Model) toggleTaskComplete(task parser.Task) tea.Cmd {
+	title := prd.NormalizeTitle(task.Title)
+	status := "✓ Completed: " + title
+	if task.Complete {
+		status = "◌ Reopened: " + title
+	}
+	return m.editPRD(task, status, title, func(e prd.Editor) error {
+		i, err := e.Locate(task.Title, task.Line)
+		if err != nil {
+			return err
+		}
+		e.SetComplete(i, !task.Complete)
+		return nil
+	})
+}
+
+// toggleTaskOwner swaps a task between 🤖 and 🧑
+func (m Model) toggleTaskOwner(task parser.Task) tea.Cmd {
+	title := prd.NormalizeTitle(task.Title)
+	owner := prd.MarkerHuman
+	if strings.Contains(task.Title, prd.MarkerHuman) {
+		owner = prd.MarkerAI
+	}
+	return m.editPRD(task, owner+" "+title, title, func(e prd.Editor) error {
+		i, err := e.Locate(task.Title, task.Line)
+		if err != nil {
+			return err
+		}
+		e.ToggleOwner(i)
+		return nil
+	})
+}
+
+// moveTask raises (delta < 0) or lowers the priority of a task
+func (m Model) moveTask(task parser.Task, delta int) tea.Cmd {
+	title := prd.NormalizeTitle(task.Title)
+	status := "↑ Moved up: " + title
+	if delta > 0 {
+		status = "↓ Moved down: " + title
+	}
+	return m.editPRD(task, status, title, func(e prd.Editor) error {
+		i, err := e.Locate(task.Title, task.Line)
+		if err != nil {
+			return err
+		}
+		_, err = e.Move(i, delta)
+		return err
+	})
+}
+
+// editPRD applies fn to the PRD file holding task and reports the result,
+// selecting the task titled selectTitle once the task list is reloaded
+func (m Model) editPRD(task parser.Task, status, selectTitle string, fn func(e prd.Editor) error) tea.Cmd {
+	source := m.project.Source(task)
+	return func() tea.Msg {
+		if err := source.Edit(fn); err != nil {
+			return EditResultMsg{Err: err}
+		}
+		return EditResultMsg{Status: status, Select: selectTitle}
+	}
+}
+
+// taskBadges renders a task's priority, unless it's the default, and tags
+func (m Model) taskBadges(task parser.Task) string {
+	var badges strings.Builder
+	if task.Priority != parser.DefaultPriority {
+		badges.WriteString(" " + m.theme.TaskPriority.Render(prd.FormatPriority(task.Priority)))
+	}
+	for _, tag := range task.Tags {
+		badges.WriteString(" " + m.theme.TaskTag.Render("#"+tag))
+	}
+	return badges.String()
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xaelophone/ralph-setup/internal/prompt"
+	"github.com/xaelophone/ralph-setup/internal/runner"
+)
+
+// terminalSize is the size of the Output pane's content area, which the
+// legacy PTY and its emulated screen are kept at
+func (m Model) terminalSize() (cols, rows int) {
+	mainWidth := m.width - m.calculateSidebarWidth() - 1
+	mainHeight := m.height - 3
+	// Border padding on each side; title, blank line, blank line and footer
+	return max(1, mainWidth-2), max(1, mainHeight-6)
+}
+
+// resizeTerminal fits the emulated screen and Claude's PTY to the Output pane
+func (m *Model) resizeTerminal() {
+	if m.term == nil {
+		return
+	}
+	cols, rows := m.terminalSize()
+	m.term.Resize(cols, rows)
+	if m.runner != nil {
+		m.runner.Resize(cols, rows)
+	}
+}
+
+// writeTerminal feeds PTY output to the emulated screen, answering any
+// queries the program made about the terminal
+func (m *Model) writeTerminal(output string) {
+	m.term.Write([]byte(output))
+	m.termOutput = true
+	if replies := m.term.TakeReplies(); len(replies) > 0 && m.runner != nil {
+		m.runner.SendInput(string(replies))
+	}
+}
+
+// Timing of the keystrokes that continue a legacy session
+const (
+	clearDelay  = 2 * time.Second        // For /clear to reset the session
+	submitDelay = 200 * time.Millisecond // Between pasting the prompt and Enter
+)
+
+// handleCompletion shows a token the legacy CLI output and, with
+// auto-continue, moves the session on to the next task
+func (m *Model) handleCompletion(msg runner.CompletionMsg) tea.Cmd {
+	cmds := []tea.Cmd{m.loadTasks(), m.loadProgress(), m.loadHandoff()}
+	if msg.Blocked {
+		m.legacyStatus = m.theme.Error.Render("⛔ BLOCKED")
+		task := ""
+		if t := m.getCurrentTask(); t != nil {
+			task = t.Title
+		}
+		return tea.Batch(append(cmds, alert(m.alerts.Blocked, "Task blocked", task))...)
+	}
+
+	m.legacyStatus = m.theme.Success.Render("✓ COMPLETE")
+	if m.autoContinue && m.nextPrompt != nil && m.runner != nil {
+		m.iteration = max(m.iteration, 1) + 1
+		cmds = append(cmds, m.continueSession(m.iteration, m.term.BracketedPaste()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// continueSession clears the CLI's context and submits the prompt for the
+// next task, as the orchestrator does between iterations
+func (m Model) continueSession(iteration int, bracketed bool) tea.Cmd {
+	r, next := m.runner, m.nextPrompt
+	return func() tea.Msg {
+		text, ok, err := next(iteration)
+		if err != nil || !ok {
+			return ContinuedMsg{Iteration: iteration, Done: !ok, Err: err}
+		}
+
+		// Pasted, the prompt's newlines don't submit it early
+		if bracketed {
+			text = "\x1b[200~" + text + "\x1b[201~"
+		} else {
+			text = strings.Join(strings.Fields(text), " ")
+		}
+
+		r.SendInput("/clear\r")
+		time.Sleep(clearDelay)
+		r.SendInput(text)
+		time.Sleep(submitDelay)
+		r.SendInput("\r")
+		prompt.NotesSent()
+		return ContinuedMsg{Iteration: iteration}
+	}
+}
+
+// handleContinued shows how continuing the session went
+func (m *Model) handleContinued(msg ContinuedMsg) tea.Cmd {
+	switch {
+	case msg.Err != nil:
+		m.legacyStatus = m.theme.Error.Render("✗ next prompt: " + msg.Err.Error())
+	case msg.Done:
+		m.legacyStatus = m.theme.Success.Render("✓ All tasks done")
+		return alert(m.alerts.Stopped, "Stopped", "All tasks done")
+	default:
+		m.legacyStatus = m.theme.StatusRunning.Render("⟳ iter " + itoa(msg.Iteration))
+	}
+	return nil
+}
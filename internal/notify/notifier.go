@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xaelophone/ralph-setup/internal/config"
+	"github.com/xaelophone/ralph-setup/internal/events"
+	"github.com/xaelophone/ralph-setup/internal/orchestrator"
+)
+
+// Notifier delivers session events to webhooks in the background
+type Notifier struct {
+	webhooks []webhook
+	retries  int
+	backoff  time.Duration
+	http     *http.Client
+	queue    chan delivery
+	program  *tea.Program // Receives delivery failures, if set
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	closed   bool
+}
+
+// webhook is a configured target with its parsed message template
+type webhook struct {
+	config.WebhookConfig
+	tmpl *template.Template
+}
+
+// delivery is a queued event for a single webhook
+type delivery struct {
+	hook  *webhook
+	event events.Event
+}
+
+// New creates a notifier for the configured webhooks and starts its worker.
+// Returns an error if a webhook has an unknown format or event, or an invalid
+// template.
+func New(cfg config.NotificationConfig) (*Notifier, error) {
+	n := &Notifier{
+		retries: cfg.Retries,
+		backoff: time.Second,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan delivery, 100),
+	}
+	if n.retries <= 0 {
+		n.retries = 3
+	}
+
+	for _, wc := range cfg.Webhooks {
+		if wc.URL == "" {
+			return nil, fmt.Errorf("webhook is missing a url")
+		}
+		if _, ok := formats[formatName(wc.Format)]; !ok {
+			return nil, fmt.Errorf("unknown webhook format %q (use json, slack, discord or ntfy)", wc.Format)
+		}
+		for _, name := range wc.Events {
+			if !knownEvent(name) {
+				return nil, fmt.Errorf("unknown event %q for %s (use %s)", name, wc.URL, eventNames())
+			}
+		}
+
+		hook := webhook{WebhookConfig: wc}
+		if wc.Template != "" {
+			tmpl, err := template.New("webhook").Parse(wc.Template)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template for %s: %w", wc.URL, err)
+			}
+			hook.tmpl = tmpl
+		}
+		n.webhooks = append(n.webhooks, hook)
+	}
+
+	n.wg.Add(1)
+	go n.run()
+
+	return n, nil
+}
+
+// SetProgram reports delivery failures in the program's output pane. Without
+// a program they are dropped, since logging would draw over the TUI.
+func (n *Notifier) SetProgram(p *tea.Program) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.program = p
+}
+
+// knownEvent reports whether name is an event type webhooks can subscribe to
+func knownEvent(name string) bool {
+	for _, t := range events.All {
+		if string(t) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// eventNames lists the event types for error messages
+func eventNames() string {
+	names := make([]string, len(events.All))
+	for i, t := range events.All {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Enabled reports whether any webhooks are configured
+func (n *Notifier) Enabled() bool {
+	return len(n.webhooks) > 0
+}
+
+// Notify queues an event for every webhook subscribed to it. It never blocks;
+// events are dropped if the queue is full.
+func (n *Notifier) Notify(event events.Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+
+	for i := range n.webhooks {
+		hook := &n.webhooks[i]
+		if !hook.Wants(string(event.Type)) {
+			continue
+		}
+		select {
+		case n.queue <- delivery{hook: hook, event: event}:
+		default:
+			n.reportLocked(fmt.Sprintf("queue full, dropping %s for %s", event.Type, hook.URL))
+		}
+	}
+}
+
+// Close stops accepting events and waits up to timeout for pending deliveries
+func (n *Notifier) Close(timeout time.Duration) {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// run delivers queued events one at a time
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	for d := range n.queue {
+		if err := n.deliver(d.hook, d.event); err != nil {
+			n.report(err.Error())
+		}
+	}
+}
+
+// report shows a delivery problem in the output pane
+func (n *Notifier) report(msg string) {
+	n.mu.Lock()
+	p := n.program
+	n.mu.Unlock()
+	send(p, msg)
+}
+
+// reportLocked is report for callers holding n.mu
+func (n *Notifier) reportLocked(msg string) {
+	send(n.program, msg)
+}
+
+func send(p *tea.Program, msg string) {
+	if p != nil {
+		p.Send(orchestrator.OutputMsg{Content: "[notify] " + msg, Raw: true})
+	}
+}
+
+// deliver sends an event, retrying with exponential backoff on network
+// errors, rate limiting and server errors
+func (n *Notifier) deliver(hook *webhook, event events.Event) error {
+	req, err := buildRequest(hook, event)
+	if err != nil {
+		return err
+	}
+
+	delay := n.backoff
+	var lastErr error
+
+	for attempt := 0; attempt <= n.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		lastErr = n.send(req)
+		if lastErr == nil {
+			return nil
+		}
+		if !retryable(lastErr) {
+			break
+		}
+	}
+
+	return fmt.Errorf("delivering %s to %s: %w", event.Type, req.url, lastErr)
+}
+
+// send performs a single delivery attempt
+func (n *Notifier) send(r *request) error {
+	req, err := http.NewRequest("POST", r.url, bytes.NewReader(r.body))
+	if err != nil {
+		return permanentError{err}
+	}
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return err // Network errors are retryable
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		return permanentError{fmt.Errorf("server returned %s", resp.Status)}
+	}
+	return nil
+}
+
+// permanentError marks delivery failures that should not be retried
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func retryable(err error) bool {
+	_, permanent := err.(permanentError)
+	return !permanent
+}
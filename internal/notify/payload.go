@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/events"
+)
+
+// request is a fully rendered webhook delivery
+type request struct {
+	url     string
+	body    []byte
+	headers map[string]string
+}
+
+// formatter renders an event into the payload shape a service expects
+type formatter func(hookURL, text string, event events.Event) (*request, error)
+
+// formats maps webhook format names to their payload builders
+var formats = map[string]formatter{
+	"json":    formatJSON,
+	"slack":   formatSlack,
+	"discord": formatDiscord,
+	"ntfy":    formatNtfy,
+}
+
+func formatName(format string) string {
+	if format == "" {
+		return "json"
+	}
+	return strings.ToLower(format)
+}
+
+// buildRequest renders an event for a webhook, applying its template and headers
+func buildRequest(hook *webhook, event events.Event) (*request, error) {
+	text, err := messageText(hook, event)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := formats[formatName(hook.Format)](hook.URL, text, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.headers == nil {
+		req.headers = map[string]string{}
+	}
+	req.headers["Content-Type"] = "application/json"
+	for k, v := range hook.Headers {
+		req.headers[k] = v
+	}
+	return req, nil
+}
+
+// messageText renders the human-readable message, using the webhook's
+// template when configured
+func messageText(hook *webhook, event events.Event) (string, error) {
+	if hook.tmpl == nil {
+		return defaultText(event), nil
+	}
+
+	var buf bytes.Buffer
+	if err := hook.tmpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("rendering template for %s: %w", hook.URL, err)
+	}
+	return buf.String(), nil
+}
+
+// defaultText summarises an event in a single message
+func defaultText(e events.Event) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s [%s] iteration %d", e.Title(), e.Project, e.Iteration))
+	if e.Task != "" {
+		sb.WriteString("\nTask: " + e.Task)
+	}
+	if e.Message != "" {
+		sb.WriteString("\n" + e.Message)
+	}
+	sb.WriteString(fmt.Sprintf("\nProgress: %d completed, %d remaining", e.TasksCompleted, e.TasksRemaining))
+	if e.CostUSD > 0 {
+		sb.WriteString(fmt.Sprintf(" · $%.2f", e.CostUSD))
+	}
+	return sb.String()
+}
+
+// formatJSON sends the raw event with the rendered text
+func formatJSON(hookURL, text string, event events.Event) (*request, error) {
+	body, err := json.Marshal(struct {
+		events.Event
+		Text string `json:"text"`
+	}{event, text})
+	return &request{url: hookURL, body: body}, err
+}
+
+// formatSlack builds a Slack incoming webhook payload
+func formatSlack(hookURL, text string, event events.Event) (*request, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	return &request{url: hookURL, body: body}, err
+}
+
+// formatDiscord builds a Discord webhook payload (content is limited to 2000 characters)
+func formatDiscord(hookURL, text string, event events.Event) (*request, error) {
+	if runes := []rune(text); len(runes) > 2000 {
+		text = string(runes[:1997]) + "..."
+	}
+	body, err := json.Marshal(map[string]string{"content": text})
+	return &request{url: hookURL, body: body}, err
+}
+
+// formatNtfy builds an ntfy JSON publish request. The topic is taken from
+// the webhook URL path (https://ntfy.sh/<topic>) and the message is posted
+// to the server root.
+func formatNtfy(hookURL, text string, event events.Event) (*request, error) {
+	u, err := url.Parse(hookURL)
+	if err != nil {
+		return nil, err
+	}
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" {
+		return nil, fmt.Errorf("ntfy webhook %s has no topic", hookURL)
+	}
+	u.Path = "/"
+
+	priority := 3
+	tags := []string{"robot"}
+	switch event.Type {
+	case events.TaskBlocked, events.SessionFailed, events.BudgetExhausted:
+		priority = 4
+		tags = []string{"warning"}
+	case events.SessionFinished:
+		tags = []string{"checkered_flag"}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"topic":    topic,
+		"title":    event.Title(),
+		"message":  text,
+		"priority": priority,
+		"tags":     tags,
+	})
+	return &request{url: u.String(), body: body}, err
+}
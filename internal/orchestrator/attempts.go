@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"fmt"
+
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
+)
+
+// BlockedMarker flags PRD tasks the orchestrator gave up on. Tasks carrying
+// it are skipped when picking the next task.
+const BlockedMarker = "⛔"
+
+// recordAttempt counts a failed attempt at task and returns the total
+func (o *Orchestrator) recordAttempt(task string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.session.TaskAttempts == nil {
+		o.session.TaskAttempts = make(map[string]int)
+	}
+	o.session.TaskAttempts[task]++
+	return o.session.TaskAttempts[task]
+}
+
+// resetRetriedTask forgets the failed attempts of a task that had used them
+// up but was picked again, which means a human removed its blocked marker
+// (e.g. from the TUI inbox) to hand it back to the agent
+func (o *Orchestrator) resetRetriedTask(task string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.attemptsExhausted(o.session.TaskAttempts[task]) {
+		delete(o.session.TaskAttempts, task)
+	}
+}
+
+// attemptsExhausted reports whether task has used up its attempts
+func (o *Orchestrator) attemptsExhausted(attempts int) bool {
+	return o.config.MaxTaskAttempts > 0 && attempts >= o.config.MaxTaskAttempts
+}
+
+// maxConsecutiveFailures is how many failed iterations in a row abort the
+// session. It is never below the per-task limit, so a task is marked blocked
+// and skipped before the whole run is given up.
+func (o *Orchestrator) maxConsecutiveFailures() int {
+	return max(3, o.config.MaxTaskAttempts)
+}
+
+// giveUpOnTask records a task that failed too often in HANDOFF.md and marks
+// it blocked in the PRD, so the loop moves on to the next runnable task
+func (o *Orchestrator) giveUpOnTask(task string, attempts int, logFile string) {
+	reason := fmt.Sprintf("No completion token after %d attempts.", attempts)
+	if n := len(o.recentMessages); n > 0 {
+		reason += "\n\nLast agent message:\n" + o.recentMessages[n-1]
+	}
+	o.writeHandoff(task, reason, logFile)
+
+	note := fmt.Sprintf("%s blocked after %d attempts", BlockedMarker, attempts)
+	source := o.config.PRD.Source(parser.Task{File: o.session.CurrentFile})
+	err := source.Edit(func(e prd.Editor) error {
+		i, err := e.Locate(task, 0)
+		if err != nil {
+			return err
+		}
+		e.Block(i, note)
+		return nil
+	})
+	if err != nil {
+		o.program.Send(OutputMsg{Content: fmt.Sprintf("[prd] failed to mark %q blocked in %s: %v", task, source.Path(), err), Raw: true})
+	}
+}
@@ -0,0 +1,101 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits for the failure digest, which is repeated in the next prompt
+const (
+	digestMessages    = 3
+	digestMessageLen  = 500
+	digestStderrLines = 15
+	digestToolErrors  = 5
+	digestDiffLines   = 20
+)
+
+// previousFailure is the digest of a failed iteration, offered to the next
+// attempt at the same task
+type previousFailure struct {
+	task   string
+	digest string
+}
+
+// recordMessage keeps the last few agent messages of the iteration, for the
+// failure digest and blocked reasons
+func (o *Orchestrator) recordMessage(content string) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return
+	}
+	o.recentMessages = append(o.recentMessages, content)
+	if len(o.recentMessages) > digestMessages {
+		o.recentMessages = o.recentMessages[1:]
+	}
+}
+
+// recordStderr keeps the tail of the CLI's stderr
+func (o *Orchestrator) recordStderr(line string) {
+	o.stderrTail = append(o.stderrTail, line)
+	if len(o.stderrTail) > digestStderrLines {
+		o.stderrTail = o.stderrTail[1:]
+	}
+}
+
+// failureDigest summarises why an iteration failed: how it ended, what the
+// agent said last, which tool calls errored, stderr, and uncommitted changes
+func (o *Orchestrator) failureDigest(result IterationResult) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Iteration %d worked on this task and stopped without a completion token", result.Iteration))
+	if result.Err != nil {
+		sb.WriteString(fmt.Sprintf(" (%v)", result.Err))
+	}
+	sb.WriteString(". Pick up where it left off rather than starting over.\n")
+
+	if len(o.recentMessages) > 0 {
+		sb.WriteString("\nLast agent messages:\n")
+		for _, msg := range o.recentMessages {
+			sb.WriteString("> " + strings.ReplaceAll(truncate(msg, digestMessageLen), "\n", "\n> ") + "\n")
+		}
+	}
+
+	var failed []SubagentTrace
+	for _, trace := range result.Subagents {
+		if trace.Status == SubagentStatusError {
+			failed = append(failed, trace)
+		}
+	}
+	if len(failed) > digestToolErrors {
+		failed = failed[len(failed)-digestToolErrors:]
+	}
+	if len(failed) > 0 {
+		sb.WriteString("\nFailed tool calls:\n")
+		for _, trace := range failed {
+			line := "- " + trace.Type
+			if trace.Input != "" {
+				line += " `" + trace.Input + "`"
+			}
+			if out := strings.TrimSpace(trace.Output); out != "" {
+				line += ": " + strings.ReplaceAll(out, "\n", " ")
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	if len(o.stderrTail) > 0 {
+		sb.WriteString("\nStderr (last lines):\n")
+		for _, line := range o.stderrTail {
+			sb.WriteString("    " + line + "\n")
+		}
+	}
+
+	if diff, err := gitOutput("diff", "--stat", "HEAD", "--", ".", ":(exclude)progress.txt"); err == nil && diff != "" {
+		sb.WriteString("\nUncommitted changes (git diff --stat):\n")
+		for _, line := range strings.Split(headLines(diff, digestDiffLines), "\n") {
+			sb.WriteString("    " + strings.TrimSpace(line) + "\n")
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
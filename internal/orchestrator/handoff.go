@@ -0,0 +1,168 @@
+package orchestrator
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/xaelophone/ralph-setup/internal/cli"
+	"github.com/xaelophone/ralph-setup/internal/fsutil"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+)
+
+// HandoffFile records tasks that need human attention
+const HandoffFile = "HANDOFF.md"
+
+// Limits for HANDOFF.md entries
+const (
+	handoffSteps = 10
+	handoffFiles = 20
+)
+
+// reasonPattern extracts the explanation the agent gives with the blocked token
+var reasonPattern = regexp.MustCompile(`(?s)<reason>(.*?)</reason>`)
+
+// fileTools are tools whose input summary is the path they modify
+var fileTools = map[string]bool{
+	"Edit": true, "MultiEdit": true, "Write": true, "NotebookEdit": true,
+}
+
+// lookupTools only read the project and aren't worth listing as steps
+var lookupTools = map[string]bool{
+	"Read": true, "Glob": true, "Grep": true, "LS": true, "TodoWrite": true,
+}
+
+// writeHandoff records a blocked task in HANDOFF.md with the reason, the
+// steps the agent tried and the files it touched. A task blocked again
+// updates its existing entry instead of adding a duplicate.
+func (o *Orchestrator) writeHandoff(task, reason, logFile string) {
+	handoff, err := parser.ParseHandoff(HandoffFile)
+	if err != nil {
+		handoff = &parser.Handoff{}
+	}
+
+	handoff.Record(parser.HandoffEntry{
+		Timestamp: time.Now().Format("2006-01-02 15:04"),
+		Task:      task,
+		Reason:    reason,
+		Attempted: o.attemptedSteps(),
+		Files:     o.touchedFiles(),
+		LogFile:   logFile,
+	})
+
+	if err := fsutil.WriteAtomic(HandoffFile, []byte(handoff.String())); err != nil && o.program != nil {
+		o.program.Send(OutputMsg{Content: "[handoff] " + err.Error(), Raw: true})
+	}
+}
+
+// blockedReason returns the agent's explanation for a blocked task: the
+// <reason> payload if given, otherwise the message carrying the blocked
+// token (or the one before it, if the token stood alone)
+func (o *Orchestrator) blockedReason() string {
+	for i := len(o.recentMessages) - 1; i >= 0; i-- {
+		if match := reasonPattern.FindStringSubmatch(o.recentMessages[i]); match != nil {
+			return strings.TrimSpace(match[1])
+		}
+	}
+
+	for i := len(o.recentMessages) - 1; i >= 0; i-- {
+		msg := o.recentMessages[i]
+		if !cli.ContainsBlockedToken(msg) {
+			continue
+		}
+		if text := strings.TrimSpace(strings.ReplaceAll(msg, CompletionTokenBlocked, "")); text != "" {
+			return text
+		}
+		if i > 0 {
+			return o.recentMessages[i-1]
+		}
+	}
+
+	if n := len(o.recentMessages); n > 0 {
+		return o.recentMessages[n-1]
+	}
+	return ""
+}
+
+// attemptedSteps lists the distinct commands and delegations the agent ran
+// this iteration (file edits are listed as touched files instead)
+func (o *Orchestrator) attemptedSteps() []string {
+	var steps []string
+	seen := map[string]bool{}
+	for _, trace := range o.currentSubagents {
+		if fileTools[trace.Type] || lookupTools[trace.Type] || trace.Input == "" || seen[trace.Input] {
+			continue
+		}
+		seen[trace.Input] = true
+		steps = append(steps, trace.Input)
+	}
+	if len(steps) > handoffSteps {
+		steps = steps[len(steps)-handoffSteps:]
+	}
+	return steps
+}
+
+// touchedFiles lists files the agent edited and files with uncommitted changes
+func (o *Orchestrator) touchedFiles() []string {
+	var files []string
+	seen := map[string]bool{}
+	add := func(file string) {
+		// progress.txt is the orchestrator's own log, not the agent's work
+		if file == "progress.txt" {
+			return
+		}
+		if file != "" && !seen[file] && len(files) < handoffFiles {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, trace := range o.currentSubagents {
+		if fileTools[trace.Type] {
+			add(relativePath(trace.Input, o.session.WorkingDir))
+		}
+	}
+
+	if status, err := gitOutput("status", "--porcelain"); err == nil {
+		for _, line := range strings.Split(status, "\n") {
+			if len(line) > 3 && !strings.HasSuffix(line, HandoffFile) {
+				add(strings.TrimSpace(line[3:]))
+			}
+		}
+	}
+
+	return files
+}
+
+// readHandoffTasks returns the tasks recorded as blocked in HANDOFF.md
+func readHandoffTasks() []string {
+	handoff, err := parser.ParseHandoff(HandoffFile)
+	if err != nil {
+		return nil
+	}
+	return handoff.Tasks()
+}
+
+// relativePath shortens paths inside the working directory
+func relativePath(path, dir string) string {
+	if rel, ok := strings.CutPrefix(path, dir+string(os.PathSeparator)); ok {
+		return rel
+	}
+	return path
+}
+
+// RemoveHandoff deletes the HANDOFF.md entry for task
+func RemoveHandoff(task string) error {
+	handoff, err := parser.ParseHandoff(HandoffFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !handoff.Remove(task) {
+		return nil
+	}
+	return fsutil.WriteAtomic(HandoffFile, []byte(handoff.String()))
+}
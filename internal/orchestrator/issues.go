@@ -0,0 +1,37 @@
+package orchestrator
+
+import (
+	"fmt"
+
+	"github.com/xaelophone/ralph-setup/internal/issues"
+)
+
+// reportIssueCompletion closes the sub-issue (or ticks the task list item)
+// tracking a completed task when the project is linked to an issue
+func (o *Orchestrator) reportIssueCompletion(task string) {
+	number, ok := issues.TaskIssue(task)
+	if !ok {
+		return
+	}
+
+	link, err := issues.LoadLink()
+	if err != nil {
+		return // Not linked - nothing to report
+	}
+
+	tracker, err := issues.NewTracker(link, o.config.IssueTracker)
+	if err != nil {
+		o.program.Send(OutputMsg{Content: "[issue] " + err.Error(), Raw: true})
+		return
+	}
+
+	commit, _ := issues.HeadCommit()
+	done, err := issues.CompleteTask(tracker, link.Issue, task, commit)
+	if err != nil {
+		o.program.Send(OutputMsg{Content: fmt.Sprintf("[issue] failed to update #%d: %v", number, err), Raw: true})
+		return
+	}
+	if done {
+		o.program.Send(OutputMsg{Content: fmt.Sprintf("[issue] marked #%d complete on %s (%s)", number, link.Repo, tracker.Name()), Raw: true})
+	}
+}
@@ -0,0 +1,23 @@
+package orchestrator
+
+// Observer receives fine-grained loop activity, for metrics and tracing.
+// Methods are called synchronously from the orchestrator goroutines and must
+// not block; the session must not be retained or modified.
+type Observer interface {
+	// IterationStarted is called before the CLI is launched for an iteration
+	IterationStarted(session *Session, task string)
+
+	// ToolFinished is called when a tool call completes or fails
+	ToolFinished(session *Session, trace SubagentTrace)
+
+	// IterationFinished is called once the iteration's outcome is known
+	IterationFinished(session *Session, result IterationResult, consecutiveFailures int)
+
+	// SessionEnded is called when the loop exits, with the final session status
+	SessionEnded(session *Session)
+}
+
+// AddObserver registers an observer for loop activity. Must be called before Start.
+func (o *Orchestrator) AddObserver(obs Observer) {
+	o.observers = append(o.observers, obs)
+}
@@ -3,12 +3,14 @@ package orchestrator
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,29 +19,41 @@ import (
 	"github.com/google/uuid"
 	"github.com/xaelophone/ralph-setup/internal/cli"
 	"github.com/xaelophone/ralph-setup/internal/config"
+	"github.com/xaelophone/ralph-setup/internal/events"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
 )
 
 // Config holds orchestrator configuration
 type Config struct {
-	MaxIterations int
-	RestartDelay  time.Duration
-	ContextLines  int
-	LogDir        string
-	SessionFile   string
-	LockFile      string
-	CLIConfig     config.CLIConfig // CLI backend configuration
+	MaxIterations   int
+	RestartDelay    time.Duration
+	ContextLines    int // Lines of progress.txt used when it has no parseable entries
+	ContextTokens   int // Estimated token budget for progress entries in the prompt
+	LogDir          string
+	SessionFile     string
+	LockFile        string
+	CLIConfig       config.CLIConfig          // CLI backend configuration
+	MaxBudgetUSD    float64                   // Stop once the session costs this much (0 = unlimited)
+	MaxTaskAttempts int                       // Failed attempts before a task is marked blocked (0 = unlimited)
+	PullRequest     config.PullRequestConfig  // End-of-session pull request
+	IssueTracker    config.IssueTrackerConfig // Forge used for linked issues
+	PRD             prd.Project               // PRD files (epics) to work through
+	Selection       prd.Selection             // Tags and priorities the run is limited to
 }
 
 // DefaultConfig returns default orchestrator configuration
 func DefaultConfig() Config {
 	return Config{
-		MaxIterations: 100,
-		RestartDelay:  3 * time.Second,
-		ContextLines:  20,
-		LogDir:        ".ralph-logs",
-		SessionFile:   ".ralph-session.json",
-		LockFile:      ".ralph.lock",
-		CLIConfig:     config.DefaultCLIConfig(),
+		MaxIterations:   100,
+		RestartDelay:    3 * time.Second,
+		ContextLines:    20,
+		ContextTokens:   1500,
+		MaxTaskAttempts: 3,
+		LogDir:          ".ralph-logs",
+		SessionFile:     ".ralph-session.json",
+		LockFile:        ".ralph.lock",
+		CLIConfig:       config.DefaultCLIConfig(),
 	}
 }
 
@@ -56,6 +70,13 @@ type Orchestrator struct {
 	// Current iteration state
 	currentSubagents []SubagentTrace
 	outputBuffer     strings.Builder
+	recentMessages   []string
+	stderrTail       []string
+	lastFailure      *previousFailure
+
+	// Event listeners (notifiers, metrics)
+	listeners []events.Listener
+	observers []Observer
 
 	// Process management
 	cmd     *exec.Cmd
@@ -125,6 +146,35 @@ type StoppedMsg struct {
 	Reason string
 }
 
+// AddListener registers a listener for session events. Must be called before Start.
+func (o *Orchestrator) AddListener(l events.Listener) {
+	o.listeners = append(o.listeners, l)
+}
+
+// emit delivers a session event to all listeners
+func (o *Orchestrator) emit(eventType events.Type, task, message, logFile string) {
+	if len(o.listeners) == 0 {
+		return
+	}
+
+	event := events.Event{
+		Type:           eventType,
+		Time:           time.Now(),
+		SessionID:      o.session.ID,
+		Project:        filepath.Base(o.session.WorkingDir),
+		Iteration:      o.session.Iteration,
+		Task:           task,
+		Message:        message,
+		LogFile:        logFile,
+		TasksCompleted: o.session.TasksCompleted,
+		TasksRemaining: o.session.TasksRemaining,
+		CostUSD:        o.session.CostUSD,
+	}
+	for _, l := range o.listeners {
+		l(event)
+	}
+}
+
 // Start begins the orchestration loop
 func (o *Orchestrator) Start() error {
 	o.mu.Lock()
@@ -235,6 +285,15 @@ func (o *Orchestrator) saveSession() error {
 func (o *Orchestrator) runLoop() {
 	defer func() {
 		os.Remove(o.config.LockFile)
+		for _, obs := range o.observers {
+			obs.SessionEnded(o.session)
+		}
+		if o.session.Status == SessionStatusCompleted {
+			o.emit(events.SessionFinished, "", "", "")
+		}
+		if o.session.Status != SessionStatusInterrupted {
+			o.openPullRequest()
+		}
 		o.program.Send(StoppedMsg{Reason: "loop ended"})
 	}()
 
@@ -251,7 +310,8 @@ func (o *Orchestrator) runLoop() {
 		o.saveSession()
 
 		// Check if we should continue
-		shouldContinue, currentTask, tasksRemaining := o.checkTasks()
+		shouldContinue, currentTask, currentFile, tasksRemaining := o.checkTasks()
+		o.session.TasksRemaining = tasksRemaining
 		if !shouldContinue {
 			o.session.Status = SessionStatusCompleted
 			o.saveSession()
@@ -259,6 +319,8 @@ func (o *Orchestrator) runLoop() {
 		}
 
 		o.session.CurrentTask = currentTask
+		o.session.CurrentFile = currentFile
+		o.resetRetriedTask(currentTask)
 		o.saveSession()
 
 		// Send status update
@@ -270,28 +332,76 @@ func (o *Orchestrator) runLoop() {
 			TasksRemaining: tasksRemaining,
 		})
 
+		for _, obs := range o.observers {
+			obs.IterationStarted(o.session, currentTask)
+		}
+
 		// Run Claude iteration
 		result := o.runIteration()
 
 		switch result.Status {
 		case IterationStatusComplete:
 			o.session.TasksCompleted++
+			o.session.CompletedTasks = append(o.session.CompletedTasks, currentTask)
 			consecutiveFailures = 0
+			o.lastFailure = nil
 			o.program.Send(CompletionMsg{Status: result.Status, Task: result.Task})
+			o.emit(events.TaskCompleted, currentTask, "", result.LogFile)
+			o.reportIssueCompletion(currentTask)
 
 		case IterationStatusBlocked:
-			o.writeHandoff(currentTask, result.LogFile)
+			o.writeHandoff(currentTask, o.blockedReason(), result.LogFile)
 			consecutiveFailures = 0
+			o.lastFailure = nil
 			o.program.Send(CompletionMsg{Status: result.Status, Task: result.Task})
+			o.emit(events.TaskBlocked, currentTask, "", result.LogFile)
 
 		case IterationStatusFailed:
 			consecutiveFailures++
-			if consecutiveFailures >= 3 {
-				o.session.Status = SessionStatusFailed
-				o.saveSession()
-				o.program.Send(ErrorMsg{Error: fmt.Errorf("too many consecutive failures")})
-				return
+			o.lastFailure = &previousFailure{task: currentTask, digest: o.failureDigest(result)}
+
+			// A CLI that can't start says nothing about the task, so only
+			// launched iterations count towards the task's attempts
+			if !result.Started || o.config.MaxTaskAttempts <= 0 {
+				o.emit(events.IterationFailed, currentTask,
+					fmt.Sprintf("Stopped without a completion token (attempt %d/%d)", consecutiveFailures, o.maxConsecutiveFailures()), result.LogFile)
+				break
+			}
+
+			attempts := o.recordAttempt(currentTask)
+			if o.attemptsExhausted(attempts) {
+				o.giveUpOnTask(currentTask, attempts, result.LogFile)
+				consecutiveFailures = 0
+				o.lastFailure = nil
+				o.program.Send(CompletionMsg{Status: IterationStatusBlocked, Task: result.Task})
+				o.emit(events.TaskBlocked, currentTask, fmt.Sprintf("Blocked after %d failed attempts", attempts), result.LogFile)
+				break
 			}
+			o.emit(events.IterationFailed, currentTask,
+				fmt.Sprintf("Stopped without a completion token (attempt %d/%d)", attempts, o.config.MaxTaskAttempts), result.LogFile)
+		}
+
+		o.recordProgress(result)
+		for _, obs := range o.observers {
+			obs.IterationFinished(o.session, result, consecutiveFailures)
+		}
+
+		if consecutiveFailures >= o.maxConsecutiveFailures() {
+			o.session.Status = SessionStatusFailed
+			o.saveSession()
+			o.program.Send(ErrorMsg{Error: fmt.Errorf("too many consecutive failures")})
+			o.emit(events.SessionFailed, currentTask, "Too many consecutive failures", result.LogFile)
+			return
+		}
+
+		// Stop once the session has spent its budget
+		if o.config.MaxBudgetUSD > 0 && o.session.CostUSD >= o.config.MaxBudgetUSD {
+			o.session.Status = SessionStatusBudgetExhausted
+			o.saveSession()
+			msg := fmt.Sprintf("spent $%.2f of $%.2f budget", o.session.CostUSD, o.config.MaxBudgetUSD)
+			o.program.Send(ErrorMsg{Error: fmt.Errorf("budget exhausted: %s", msg)})
+			o.emit(events.BudgetExhausted, currentTask, "Budget exhausted: "+msg, result.LogFile)
+			return
 		}
 
 		// Brief delay before next iteration
@@ -310,9 +420,14 @@ func (o *Orchestrator) runIteration() IterationResult {
 		Task:      o.session.CurrentTask,
 	}
 
+	// Commits after this one were made by the iteration
+	startHead, _ := gitOutput("rev-parse", "HEAD")
+
 	// Reset iteration state
 	o.currentSubagents = nil
 	o.outputBuffer.Reset()
+	o.recentMessages = nil
+	o.stderrTail = nil
 
 	// Build the prompt
 	prompt := o.buildPrompt()
@@ -323,6 +438,7 @@ func (o *Orchestrator) runIteration() IterationResult {
 	logWriter, err := os.Create(logFile)
 	if err != nil {
 		result.Status = IterationStatusFailed
+		result.Err = err
 		return result
 	}
 	defer logWriter.Close()
@@ -337,11 +453,15 @@ func (o *Orchestrator) runIteration() IterationResult {
 
 	if err := o.cmd.Start(); err != nil {
 		result.Status = IterationStatusFailed
+		result.Err = fmt.Errorf("failed to start %s: %w", o.cliRunner.Name(), err)
 		return result
 	}
+	result.Started = true
 
 	// Send prompt via stdin
-	stdin.Write([]byte(prompt))
+	if _, err := stdin.Write([]byte(prompt)); err == nil {
+		promptSent()
+	}
 	stdin.Close()
 
 	// Parse stdout (JSONL)
@@ -367,7 +487,7 @@ func (o *Orchestrator) runIteration() IterationResult {
 				o.processNormalizedEvent(normalizedEvent)
 
 				// Check for completion tokens in content
-				if normalizedEvent.Type == cli.EventTypeMessage {
+				if normalizedEvent.Type == cli.EventTypeMessage || normalizedEvent.Type == cli.EventTypeResult {
 					if cli.ContainsCompletionToken(normalizedEvent.Content) {
 						completionDetected = true
 					}
@@ -397,15 +517,17 @@ func (o *Orchestrator) runIteration() IterationResult {
 		for scanner.Scan() {
 			line := scanner.Text()
 			logWriter.WriteString("[stderr] " + line + "\n")
+			o.recordStderr(line)
 			o.program.Send(OutputMsg{Content: "[stderr] " + line, Raw: true})
 		}
 	}()
 
 	wg.Wait()
-	o.cmd.Wait()
+	result.Err = o.cmd.Wait()
 
 	result.Duration = time.Since(startTime)
 	result.Subagents = o.currentSubagents
+	result.Commits = commitsSince(startHead)
 
 	if completionDetected {
 		result.Status = IterationStatusComplete
@@ -422,6 +544,7 @@ func (o *Orchestrator) runIteration() IterationResult {
 func (o *Orchestrator) processNormalizedEvent(event *cli.NormalizedEvent) {
 	switch event.Type {
 	case cli.EventTypeMessage:
+		o.recordMessage(event.Content)
 		o.program.Send(OutputMsg{Content: event.Content, Raw: false})
 
 	case cli.EventTypeToolStart:
@@ -438,6 +561,9 @@ func (o *Orchestrator) processNormalizedEvent(event *cli.NormalizedEvent) {
 	case cli.EventTypeToolEnd:
 		o.completeSubagent(event.ToolID, event.Content, event.IsError)
 
+	case cli.EventTypeResult, cli.EventTypeTurnComplete:
+		o.recordUsage(event.Usage)
+
 	case cli.EventTypeError:
 		o.program.Send(OutputMsg{Content: "[error] " + event.Content, Raw: true})
 	}
@@ -458,12 +584,51 @@ func (o *Orchestrator) completeSubagent(toolID, output string, isError bool) {
 				o.currentSubagents[i].Status = SubagentStatusComplete
 			}
 
+			o.recordTestRun(o.currentSubagents[i])
+			for _, obs := range o.observers {
+				obs.ToolFinished(o.session, o.currentSubagents[i])
+			}
 			o.program.Send(SubagentMsg{Trace: o.currentSubagents[i]})
 			return
 		}
 	}
 }
 
+// testCommandPattern matches shell commands that run a project's test suite
+var testCommandPattern = regexp.MustCompile(`\b(go test|npm (run )?test|yarn test|pnpm test|bun test|pytest|cargo test|make test|mvn test|gradle test|rspec|jest|vitest)\b`)
+
+// recordTestRun remembers the latest outcome of each test command for the session summary
+func (o *Orchestrator) recordTestRun(trace SubagentTrace) {
+	if !testCommandPattern.MatchString(trace.Input) {
+		return
+	}
+
+	run := TestRun{
+		Command:   trace.Input,
+		Passed:    trace.Status == SubagentStatusComplete,
+		Iteration: o.session.Iteration,
+		RanAt:     time.Now(),
+	}
+
+	for i := range o.session.TestRuns {
+		if o.session.TestRuns[i].Command == run.Command {
+			o.session.TestRuns[i] = run
+			return
+		}
+	}
+	o.session.TestRuns = append(o.session.TestRuns, run)
+}
+
+// recordUsage adds token usage and cost reported by the CLI to the session totals
+func (o *Orchestrator) recordUsage(usage *cli.Usage) {
+	if usage == nil {
+		return
+	}
+	o.session.InputTokens += usage.InputTokens
+	o.session.OutputTokens += usage.OutputTokens
+	o.session.CostUSD += usage.CostUSD
+}
+
 // extractToolInputSummary extracts a human-readable summary from tool input
 func extractToolInputSummary(input map[string]interface{}) string {
 	if input == nil {
@@ -483,103 +648,86 @@ func extractToolInputSummary(input map[string]interface{}) string {
 	return ""
 }
 
-// buildPrompt creates the prompt for Claude
-func (o *Orchestrator) buildPrompt() string {
-	recentProgress := o.getRecentProgress()
-
-	return fmt.Sprintf(`You are running under ralph-loop (iteration %d).
-
-## Current Task
-%s
-
-## Recent Progress (Last Few Iterations)
-%s
-
-## Instructions
-1. Complete the current task (or the highest-priority 🤖 task in PRD.md)
-2. Run tests and type checks - they MUST pass
-3. Update PRD.md to mark the task complete (- [x])
-4. Append to progress.txt with what you did
-5. Commit with a descriptive message
-6. Output the completion token: <promise>COMPLETE</promise>
-
-IMPORTANT: You MUST output <promise>COMPLETE</promise> after completing each task.
-This signals the orchestrator to continue to the next task.
-
-If you encounter an error you cannot resolve after 3 attempts, explain the issue
-and output <promise>BLOCKED</promise> instead.
-
-Begin working on the task now.
-`, o.session.Iteration, o.session.CurrentTask, recentProgress)
-}
-
-// checkTasks reads PRD.md and determines if we should continue
-func (o *Orchestrator) checkTasks() (shouldContinue bool, currentTask string, remaining int) {
-	data, err := os.ReadFile("PRD.md")
+// checkTasks reads the PRD files and determines if we should continue. The
+// next task is the highest-priority open top-level 🤖 task that isn't blocked,
+// is in the run's selection and whose dependencies are done; file order
+// breaks ties. With several PRD
+// files (epics), sequential order finishes each epic before the next, and
+// priority order picks across all of them. file is the PRD holding the task.
+func (o *Orchestrator) checkTasks() (shouldContinue bool, currentTask, file string, remaining int) {
+	tasks, err := o.config.PRD.Tasks()
+	if errors.Is(err, os.ErrNotExist) {
+		// No PRD yet - the agent should create one
+		if o.config.PRD.Multi() {
+			return true, "Create a PRD file matching " + strings.Join(o.config.PRD.Patterns, ", ") + " with task list", "", 0
+		}
+		return true, "Create PRD.md with task list", "", 0
+	}
 	if err != nil {
-		// No PRD.md - Claude should create one
-		return true, "Create PRD.md with task list", 0
+		// A structured PRD that doesn't parse - let the agent repair it
+		return true, fmt.Sprintf("Fix the PRD so it parses: %v", err), "", 0
 	}
 
-	content := string(data)
-	lines := strings.Split(content, "\n")
-
-	incompletePattern := regexp.MustCompile(`^- \[ \] (.+)$`)
-	aiPattern := regexp.MustCompile(`🤖`)
-
-	var aiTasks []string
-
-	for _, line := range lines {
-		if match := incompletePattern.FindStringSubmatch(line); match != nil {
-			task := match[1]
-			if aiPattern.MatchString(line) {
-				aiTasks = append(aiTasks, strings.ReplaceAll(task, "🤖", ""))
-			}
+	var aiTasks []parser.Task
+	for _, t := range tasks {
+		if t.Indent == 0 && !t.Complete && strings.Contains(t.Title, "🤖") && !strings.Contains(t.Title, BlockedMarker) {
+			aiTasks = append(aiTasks, t)
 		}
 	}
 
-	if len(aiTasks) == 0 {
-		return false, "", 0
-	}
-
-	return true, strings.TrimSpace(aiTasks[0]), len(aiTasks)
-}
-
-// getRecentProgress reads recent entries from progress.txt
-func (o *Orchestrator) getRecentProgress() string {
-	data, err := os.ReadFile("progress.txt")
-	if err != nil {
-		return "No previous progress recorded."
+	var selected, runnable []parser.Task
+	for _, t := range aiTasks {
+		if !o.config.Selection.Match(t) {
+			continue
+		}
+		selected = append(selected, t)
+		if dependenciesDone(tasks, t) {
+			runnable = append(runnable, t)
+		}
 	}
-
-	lines := strings.Split(string(data), "\n")
-	start := len(lines) - o.config.ContextLines
-	if start < 0 {
-		start = 0
+	if o.config.PRD.Order == prd.EpicOrderPriority {
+		sort.SliceStable(runnable, func(i, j int) bool { return runnable[i].Priority < runnable[j].Priority })
+	} else {
+		// Tasks are listed epic by epic; keep that order and sort within each
+		epic := make(map[string]int)
+		for _, t := range tasks {
+			if _, ok := epic[t.File]; !ok {
+				epic[t.File] = len(epic)
+			}
+		}
+		sort.SliceStable(runnable, func(i, j int) bool {
+			a, b := runnable[i], runnable[j]
+			if epic[a.File] != epic[b.File] {
+				return epic[a.File] < epic[b.File]
+			}
+			return a.Priority < b.Priority
+		})
 	}
 
-	recent := lines[start:]
-	result := strings.Join(recent, "\n")
-
-	// Limit size
-	if len(result) > 4000 {
-		result = result[:4000] + "\n... (truncated)"
+	if len(runnable) == 0 {
+		if o.program != nil {
+			switch {
+			case len(selected) > 0:
+				o.program.Send(OutputMsg{Content: fmt.Sprintf("[prd] %d 🤖 task(s) wait on unfinished dependencies", len(selected)), Raw: true})
+			case len(aiTasks) > 0:
+				o.program.Send(OutputMsg{Content: fmt.Sprintf("[prd] %d 🤖 task(s) left, none selected by %s", len(aiTasks), o.config.Selection), Raw: true})
+			}
+		}
+		return false, "", "", 0
 	}
 
-	return result
+	return true, prd.NormalizeTitle(runnable[0].Title), runnable[0].File, len(selected)
 }
 
-// writeHandoff writes blocked task info to HANDOFF.md
-func (o *Orchestrator) writeHandoff(task, logFile string) {
-	f, err := os.OpenFile("HANDOFF.md", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return
+// dependenciesDone reports whether every task t depends on is complete.
+// References that match no task are ignored (`rwatch prd lint` flags them).
+func dependenciesDone(tasks []parser.Task, t parser.Task) bool {
+	for _, ref := range t.DependsOn {
+		if i := prd.FindTask(tasks, ref); i >= 0 && !tasks[i].Complete {
+			return false
+		}
 	}
-	defer f.Close()
-
-	f.WriteString(fmt.Sprintf("\n## Blocked Task (%s)\n", time.Now().Format("2006-01-02 15:04")))
-	f.WriteString(fmt.Sprintf("- Task: %s\n", task))
-	f.WriteString(fmt.Sprintf("- See log: %s\n", logFile))
+	return true
 }
 
 // GetSession returns the current session
@@ -605,3 +753,11 @@ func truncate(s string, maxLen int) string {
 	}
 	return s[:maxLen-3] + "..."
 }
+
+func headLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = append(lines[:n], fmt.Sprintf("... (%d more)", len(lines)-n))
+	}
+	return strings.Join(lines, "\n")
+}
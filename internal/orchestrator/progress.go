@@ -0,0 +1,306 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xaelophone/ralph-setup/internal/issues"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
+)
+
+// recentEntries is how many of the latest progress entries are always
+// included (budget permitting), whatever their relevance
+const recentEntries = 3
+
+// progressPrefixPattern matches the status prefix of progress entry titles
+var progressPrefixPattern = regexp.MustCompile(`^(?i)(completed|blocked|started|failed|done)\s*:\s*`)
+
+// stopWords are ignored when matching entries to the current task
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "into": true,
+	"add": true, "use": true, "task": true, "that": true, "this": true, "when": true,
+}
+
+// getRecentProgress selects whole progress.txt entries for the prompt: the
+// latest few, then entries related to the current task (shared keywords or
+// the same PRD section), then older ones, within ContextTokens. Entries are
+// returned in file order.
+func (o *Orchestrator) getRecentProgress() string {
+	entries, err := parser.ParseProgress("progress.txt")
+	if err != nil {
+		return "No previous progress recorded."
+	}
+	if len(entries) == 0 {
+		return o.getRecentProgressLines()
+	}
+
+	sections := o.prdSections()
+	taskSection := sections[normalizeTaskTitle(o.session.CurrentTask)]
+	taskWords := keywords(o.session.CurrentTask)
+
+	type candidate struct {
+		index int
+		score int
+		text  string
+	}
+
+	candidates := make([]candidate, len(entries))
+	for i, e := range entries {
+		c := candidate{index: i, text: formatProgressEntry(e)}
+		if i >= len(entries)-recentEntries {
+			c.score = 1000 // Always prefer the latest entries
+		}
+		entryWords := keywords(e.Title + " " + strings.Join(e.Details, " "))
+		for w := range taskWords {
+			if entryWords[w] {
+				c.score++
+			}
+		}
+		if taskSection != "" && sections[normalizeTaskTitle(e.Title)] == taskSection {
+			c.score += 2
+		}
+		candidates[i] = c
+	}
+
+	// Highest score first, newest first among equals
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].index > candidates[j].index
+	})
+
+	budget := o.config.ContextTokens
+	selected := make([]bool, len(entries))
+	texts := make([]string, len(entries))
+	count := 0
+	for _, c := range candidates {
+		cost := estimateTokens(c.text)
+		if cost > budget {
+			if count == 0 {
+				// Keep at least the best entry, cut to fit
+				texts[c.index] = truncateRunes(c.text, budget*4)
+				selected[c.index] = true
+				count++
+				budget = 0
+			}
+			continue
+		}
+		texts[c.index] = c.text
+		selected[c.index] = true
+		count++
+		budget -= cost
+	}
+
+	var parts []string
+	if omitted := len(entries) - count; omitted > 0 {
+		parts = append(parts, fmt.Sprintf("(%d older or unrelated entries omitted)", omitted))
+	}
+	for i := range entries {
+		if selected[i] {
+			parts = append(parts, texts[i])
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// getRecentProgressLines falls back to the last ContextLines lines when
+// progress.txt has no timestamped entries
+func (o *Orchestrator) getRecentProgressLines() string {
+	data, err := os.ReadFile("progress.txt")
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		return "No previous progress recorded."
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > o.config.ContextLines {
+		lines = lines[len(lines)-o.config.ContextLines:]
+	}
+	return truncateRunes(strings.Join(lines, "\n"), o.config.ContextTokens*4)
+}
+
+// formatProgressEntry renders an entry the way it appears in progress.txt.
+// The orchestrator's own entries are shortened to one line, since their
+// tool counts and log paths mean little to the agent.
+func formatProgressEntry(e parser.ProgressEntry) string {
+	if e.Recorded() {
+		summary := "iteration " + e.Fields["iteration"]
+		if d := e.Fields["duration"]; d != "" {
+			summary += ", " + d
+		}
+		if c := e.Fields["commits"]; c != "" && c != "none" {
+			summary += ", commits " + c
+		}
+		return fmt.Sprintf("[%s] %s (%s)", e.Timestamp, e.Title, summary)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[%s] %s", e.Timestamp, e.Title))
+	for _, d := range e.Details {
+		sb.WriteString("\n- " + d)
+	}
+	return sb.String()
+}
+
+// prdSections maps normalised task titles to their PRD section, qualified
+// by epic when the project has several PRD files
+func (o *Orchestrator) prdSections() map[string]string {
+	sections := map[string]string{}
+	tasks, err := o.config.PRD.Tasks()
+	if err != nil {
+		return sections
+	}
+	for _, t := range tasks {
+		section := t.Section
+		if o.config.PRD.Multi() {
+			section = prd.EpicName(t.File) + " › " + section
+		}
+		sections[normalizeTaskTitle(t.Title)] = section
+	}
+	return sections
+}
+
+// normalizeTaskTitle strips owner, priority and tag markers, issue references
+// and status prefixes so PRD tasks and progress entries about them compare
+// equal
+func normalizeTaskTitle(title string) string {
+	title = strings.NewReplacer("🤖", "", "🧑", "").Replace(title)
+	title, _ = parser.SplitMarkers(title)
+	title = progressPrefixPattern.ReplaceAllString(strings.TrimSpace(title), "")
+	title = issues.StripTaskIssue(title)
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// keywords returns the distinct significant words in s
+func keywords(s string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if utf8.RuneCountInString(w) >= 3 && !stopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// estimateTokens approximates the token count of s (about four characters per token)
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// truncateRunes cuts s to at most n runes without splitting a character
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n]) + "\n... (truncated)"
+}
+
+// recordProgress appends the orchestrator's entry for a finished iteration
+// to progress.txt, after any entry the agent wrote itself. See
+// parser.ProgressEntry for the format.
+func (o *Orchestrator) recordProgress(result IterationResult) {
+	entry := formatIterationEntry(time.Now(), result)
+	if o.config.PRD.Multi() && o.session.CurrentFile != "" {
+		entry += "\n- epic: " + prd.EpicName(o.session.CurrentFile)
+	}
+
+	// Entries are separated by a blank line
+	if data, err := os.ReadFile("progress.txt"); err == nil && len(data) > 0 {
+		switch {
+		case !strings.HasSuffix(string(data), "\n"):
+			entry = "\n\n" + entry
+		case !strings.HasSuffix(string(data), "\n\n"):
+			entry = "\n" + entry
+		}
+	}
+
+	f, err := os.OpenFile("progress.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err == nil {
+		_, err = f.WriteString(entry + "\n")
+		f.Close()
+	}
+	if err != nil && o.program != nil {
+		o.program.Send(OutputMsg{Content: "[progress] failed to record iteration: " + err.Error(), Raw: true})
+	}
+}
+
+// formatIterationEntry formats the progress.txt entry for an iteration
+func formatIterationEntry(at time.Time, result IterationResult) string {
+	title := map[IterationStatus]string{
+		IterationStatusComplete: "Completed",
+		IterationStatusBlocked:  "Blocked",
+		IterationStatusFailed:   "Failed",
+		IterationStatusTimeout:  "Timed out",
+	}[result.Status]
+	if title == "" {
+		title = string(result.Status)
+	}
+
+	commits := "none"
+	if len(result.Commits) > 0 {
+		commits = strings.Join(result.Commits, ", ")
+	}
+
+	lines := []string{
+		fmt.Sprintf("[%s] %s: %s", at.Format("2006-01-02 15:04"), title, result.Task),
+		fmt.Sprintf("- iteration: %d", result.Iteration),
+		"- status: " + string(result.Status),
+		"- duration: " + result.Duration.Round(time.Second).String(),
+		"- commits: " + commits,
+	}
+	if tools := toolCounts(result.Subagents); tools != "" {
+		lines = append(lines, "- tools: "+tools)
+	}
+	if result.LogFile != "" {
+		lines = append(lines, "- log: "+result.LogFile)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toolCounts summarises tool calls as "Bash 12, Edit 5", most used first
+func toolCounts(traces []SubagentTrace) string {
+	counts := map[string]int{}
+	for _, t := range traces {
+		counts[t.Type]++
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// commitsSince lists the short hashes of commits after start, oldest first.
+// An empty start (no commits yet) lists every commit.
+func commitsSince(start string) []string {
+	rng := "HEAD"
+	if start != "" {
+		rng = start + "..HEAD"
+	}
+	out, err := gitOutput("rev-list", "--reverse", "--abbrev-commit", rng)
+	if err != nil || out == "" {
+		return nil
+	}
+	return strings.Fields(out)
+}
@@ -0,0 +1,154 @@
+package orchestrator
+
+import (
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"github.com/xaelophone/ralph-setup/internal/prd"
+	"github.com/xaelophone/ralph-setup/internal/prompt"
+)
+
+// maxGitStatusLines limits the git status passed to templates
+const maxGitStatusLines = 40
+
+// buildPrompt renders the prompt template for the current iteration. A broken
+// .ralph/prompt.tmpl is reported and the built-in template used instead, so a
+// typo doesn't stall the loop.
+func (o *Orchestrator) buildPrompt() string {
+	data := o.promptData()
+	data.Notes = prompt.TakeNotes()
+
+	text, err := prompt.Render(data)
+	if err == nil {
+		return text
+	}
+
+	if o.program != nil {
+		o.program.Send(OutputMsg{Content: "[prompt] " + err.Error() + "; using built-in template", Raw: true})
+	}
+	text, _ = prompt.RenderSource("default", prompt.Default(), data)
+	return text
+}
+
+// promptSent discards the notes taken for the prompt once the CLI has it.
+// runIteration's prompt variable shadows the package there.
+func promptSent() {
+	prompt.NotesSent()
+}
+
+// promptData gathers the template context for the current iteration
+func (o *Orchestrator) promptData() prompt.Data {
+	source := o.config.PRD.Source(parser.Task{File: o.session.CurrentFile})
+	data := prompt.Data{
+		Iteration:      o.session.Iteration,
+		Task:           o.session.CurrentTask,
+		TaskFile:       source.Path(),
+		CompleteHint:   "- [x]",
+		TaskContext:    o.taskContext(o.session.CurrentTask),
+		RecentProgress: o.getRecentProgress(),
+		Handoff:        readHandoffTasks(),
+	}
+
+	if o.lastFailure != nil && o.lastFailure.task == o.session.CurrentTask {
+		data.PreviousFailure = o.lastFailure.digest
+	}
+
+	if status, err := gitOutput("status", "--short"); err == nil {
+		data.GitStatus = headLines(status, maxGitStatusLines)
+	}
+
+	if prd.IsStructured(source.Path()) {
+		data.CompleteHint = "status: done"
+	}
+
+	return data
+}
+
+// taskContext describes where the current task sits in the PRD: its epic
+// (with several PRD files), its section and the detail lines nested under it
+// (acceptance criteria in structured PRDs). Only top-level tasks are
+// considered, matching the orchestrator's task selection.
+func (o *Orchestrator) taskContext(title string) string {
+	tasks, err := o.config.PRD.Tasks()
+	if err != nil {
+		return ""
+	}
+
+	for _, t := range tasks {
+		if t.Complete || t.Indent > 0 || prd.NormalizeTitle(t.Title) != title {
+			continue
+		}
+		if o.session.CurrentFile != "" && t.File != o.session.CurrentFile {
+			continue
+		}
+
+		var sb strings.Builder
+		if o.config.PRD.Multi() {
+			sb.WriteString("Epic: " + prd.EpicName(t.File) + "\n")
+		}
+		if t.Section != "" {
+			sb.WriteString("Section: " + t.Section + "\n")
+		}
+		if t.Priority != parser.DefaultPriority {
+			sb.WriteString("Priority: " + prd.FormatPriority(t.Priority) + "\n")
+		}
+		if len(t.Tags) > 0 {
+			sb.WriteString("Tags: " + strings.Join(t.Tags, ", ") + "\n")
+		}
+		if len(t.Details) > 0 {
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(strings.Join(t.Details, "\n") + "\n")
+		}
+		return strings.TrimRight(sb.String(), "\n")
+	}
+	return ""
+}
+
+// RenderPrompt returns the prompt the orchestrator would send for the next
+// runnable task, for `rwatch prompt --render`. A preview only reads the queued
+// notes; with takeNotes they are taken for sending, as ralph-loop does, and
+// prompt.NotesSent must be called once the CLI has the prompt.
+func RenderPrompt(config Config, iteration int, takeNotes bool) (string, error) {
+	o, _ := nextTask(config, iteration)
+	data := o.promptData()
+	if takeNotes {
+		data.Notes = prompt.TakeNotes()
+	} else {
+		data.Notes = prompt.ReadNotes()
+	}
+	return prompt.Render(data)
+}
+
+// NextPrompt returns the prompt for the next runnable task and takes the notes
+// queued for it, for continuing an interactive session in legacy mode. ok is
+// false when no task is left to run. Call prompt.NotesSent once the prompt
+// has been submitted.
+func NextPrompt(config Config, iteration int) (text string, ok bool, err error) {
+	o, ok := nextTask(config, iteration)
+	if !ok {
+		return "", false, nil
+	}
+	data := o.promptData()
+	data.Notes = prompt.TakeNotes()
+	text, err = prompt.Render(data)
+	return text, err == nil, err
+}
+
+// nextTask sets up an orchestrator on the next runnable task, reporting
+// whether there is one
+func nextTask(config Config, iteration int) (*Orchestrator, bool) {
+	o := &Orchestrator{
+		config: config,
+		session: &Session{
+			Iteration:  iteration,
+			WorkingDir: mustGetwd(),
+		},
+	}
+
+	shouldContinue, task, file, _ := o.checkTasks()
+	o.session.CurrentTask = task
+	o.session.CurrentFile = file
+	return o, shouldContinue
+}
@@ -0,0 +1,155 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/xaelophone/ralph-setup/internal/issues"
+)
+
+// openPullRequest pushes the working branch and opens (or updates) a pull
+// request summarising the session. Runs at the end of the session when
+// enabled in .ralph-config.json and at least one task was completed.
+func (o *Orchestrator) openPullRequest() {
+	cfg := o.config.PullRequest
+	if !cfg.Enabled || len(o.session.CompletedTasks) == 0 {
+		return
+	}
+
+	base := cfg.BaseBranch()
+	branch, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || branch == "HEAD" {
+		o.prStatus("skipped: not on a branch")
+		return
+	}
+	if branch == base {
+		o.prStatus(fmt.Sprintf("skipped: working branch is the base branch (%s)", base))
+		return
+	}
+
+	link, err := issues.LinkFromGit()
+	if err != nil {
+		o.prStatus(err.Error())
+		return
+	}
+	tracker, err := issues.NewTracker(link, o.config.IssueTracker)
+	if err != nil {
+		o.prStatus(err.Error())
+		return
+	}
+	gh, ok := tracker.(*issues.GitHub)
+	if !ok {
+		o.prStatus(fmt.Sprintf("skipped: pull requests are only supported on GitHub (remote is %s)", tracker.Name()))
+		return
+	}
+
+	if out, err := exec.Command("git", "push", "-u", "origin", branch).CombinedOutput(); err != nil {
+		o.prStatus(fmt.Sprintf("push failed: %v: %s", err, strings.TrimSpace(string(out))))
+		return
+	}
+
+	title := o.pullRequestTitle()
+	body := o.pullRequestBody()
+
+	pr, err := gh.FindPullRequest(branch, base)
+	if err != nil {
+		o.prStatus(err.Error())
+		return
+	}
+
+	if pr != nil {
+		pr, err = gh.UpdatePullRequest(pr.Number, title, body)
+	} else {
+		pr, err = gh.CreatePullRequest(title, body, branch, base, cfg.Draft)
+	}
+	if err != nil {
+		o.prStatus(err.Error())
+		return
+	}
+
+	if len(cfg.Labels) > 0 {
+		if err := gh.AddLabels(pr.Number, cfg.Labels); err != nil {
+			o.prStatus(fmt.Sprintf("failed to add labels: %v", err))
+		}
+	}
+
+	o.prStatus(fmt.Sprintf("#%d %s", pr.Number, pr.HTMLURL))
+}
+
+// pullRequestTitle names the PR after the task when only one was completed
+func (o *Orchestrator) pullRequestTitle() string {
+	if len(o.session.CompletedTasks) == 1 {
+		return issues.StripTaskIssue(o.session.CompletedTasks[0])
+	}
+	return fmt.Sprintf("ralph: %d tasks completed", len(o.session.CompletedTasks))
+}
+
+// pullRequestBody summarises the session: tasks, blocked items, test gates and cost
+func (o *Orchestrator) pullRequestBody() string {
+	var sb strings.Builder
+	s := o.session
+
+	sb.WriteString("## Summary\n\n")
+	sb.WriteString(fmt.Sprintf("Autonomous ralph session `%s`: %d iterations, %d tasks completed.\n\n",
+		shortID(s.ID), s.Iteration, len(s.CompletedTasks)))
+
+	sb.WriteString("### Tasks completed\n\n")
+	for _, task := range s.CompletedTasks {
+		sb.WriteString("- [x] " + task + "\n")
+	}
+
+	if blocked := readHandoffTasks(); len(blocked) > 0 {
+		sb.WriteString("\n### Blocked (see HANDOFF.md)\n\n")
+		for _, task := range blocked {
+			sb.WriteString("- [ ] " + task + "\n")
+		}
+	}
+
+	if len(s.TestRuns) > 0 {
+		sb.WriteString("\n### Test gates\n\n")
+		sb.WriteString("| Result | Command | Iteration |\n")
+		sb.WriteString("|--------|---------|-----------|\n")
+		for _, run := range s.TestRuns {
+			result := "✅ pass"
+			if !run.Passed {
+				result = "❌ fail"
+			}
+			sb.WriteString(fmt.Sprintf("| %s | `%s` | %d |\n", result, strings.ReplaceAll(run.Command, "|", "\\|"), run.Iteration))
+		}
+	}
+
+	if s.InputTokens > 0 || s.OutputTokens > 0 || s.CostUSD > 0 {
+		sb.WriteString("\n### Usage\n\n")
+		sb.WriteString(fmt.Sprintf("- Tokens: %d in / %d out\n", s.InputTokens, s.OutputTokens))
+		if s.CostUSD > 0 {
+			sb.WriteString(fmt.Sprintf("- Cost: $%.2f\n", s.CostUSD))
+		}
+		sb.WriteString(fmt.Sprintf("- Duration: %s\n", time.Since(s.StartedAt).Round(time.Second)))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n---\n_Opened by rwatch at %s_\n", time.Now().Format("2006-01-02 15:04")))
+	return sb.String()
+}
+
+// prStatus reports pull request progress in the output pane
+func (o *Orchestrator) prStatus(msg string) {
+	o.program.Send(OutputMsg{Content: "[pr] " + msg, Raw: true})
+}
+
+// gitOutput runs a git command and returns its trimmed output
+func gitOutput(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -65,20 +65,37 @@ type Session struct {
 	Status          SessionStatus    `json:"status"`
 	Iteration       int              `json:"iteration"`
 	TasksCompleted  int              `json:"tasks_completed"`
+	TasksRemaining  int              `json:"tasks_remaining"`
 	CurrentTask     string           `json:"current_task,omitempty"`
+	CurrentFile     string           `json:"current_file,omitempty"` // PRD file holding CurrentTask
 	WorkingDir      string           `json:"working_dir"`
 	PID             int              `json:"pid"`
 	SubagentTraces  []SubagentTrace  `json:"subagent_traces,omitempty"`
+	CompletedTasks  []string         `json:"completed_tasks,omitempty"`
+	TestRuns        []TestRun        `json:"test_runs,omitempty"`
+	InputTokens     int              `json:"input_tokens,omitempty"`
+	OutputTokens    int              `json:"output_tokens,omitempty"`
+	CostUSD         float64          `json:"cost_usd,omitempty"`
+	TaskAttempts    map[string]int   `json:"task_attempts,omitempty"` // Failed attempts per task
+}
+
+// TestRun records the latest outcome of a test command run by the agent
+type TestRun struct {
+	Command   string    `json:"command"`
+	Passed    bool      `json:"passed"`
+	Iteration int       `json:"iteration"`
+	RanAt     time.Time `json:"ran_at"`
 }
 
 type SessionStatus string
 
 const (
-	SessionStatusRunning     SessionStatus = "running"
-	SessionStatusCompleted   SessionStatus = "completed"
-	SessionStatusInterrupted SessionStatus = "interrupted"
-	SessionStatusFailed      SessionStatus = "failed"
-	SessionStatusRecovered   SessionStatus = "recovered"
+	SessionStatusRunning         SessionStatus = "running"
+	SessionStatusCompleted       SessionStatus = "completed"
+	SessionStatusInterrupted     SessionStatus = "interrupted"
+	SessionStatusFailed          SessionStatus = "failed"
+	SessionStatusRecovered       SessionStatus = "recovered"
+	SessionStatusBudgetExhausted SessionStatus = "budget_exhausted"
 )
 
 // Task represents a task from PRD.md
@@ -98,6 +115,9 @@ type IterationResult struct {
 	Duration    time.Duration
 	Subagents   []SubagentTrace
 	LogFile     string
+	Started     bool     // The CLI process was launched
+	Err         error    // Why the CLI failed to start or exited non-zero
+	Commits     []string // Short hashes of commits made during the iteration, oldest first
 }
 
 type IterationStatus string
@@ -114,12 +134,3 @@ const (
 	CompletionTokenComplete = "<promise>COMPLETE</promise>"
 	CompletionTokenBlocked  = "<promise>BLOCKED</promise>"
 )
-
-// PromptContext holds the context for building prompts
-type PromptContext struct {
-	Iteration       int
-	CurrentTask     string
-	RecentProgress  string
-	TasksSummary    string
-	SubagentHistory string
-}
@@ -0,0 +1,253 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HandoffEntry is a blocked task recorded in HANDOFF.md
+type HandoffEntry struct {
+	Timestamp string
+	Task      string
+	Reason    string
+	Attempted []string // Steps the agent tried (commands run)
+	Files     []string // Files the agent touched
+	LogFile   string
+	Count     int      // Times the task has been blocked
+	Notes     []string // Unrecognised lines, kept so hand edits survive rewrites
+	Line      int
+}
+
+// Handoff is the parsed content of HANDOFF.md
+type Handoff struct {
+	Preamble []string // Lines before the first entry
+	Entries  []HandoffEntry
+}
+
+var (
+	// Match entry headers: ## Blocked Task (2024-01-15 14:32)
+	handoffHeaderPattern = regexp.MustCompile(`^##\s+Blocked Task(?:\s+\(([^)]*)\))?\s*$`)
+	// Match entry fields: - Task: description
+	handoffFieldPattern = regexp.MustCompile(`^-\s*(Task|Reason|See log|Log|Times blocked):\s*(.*)$`)
+	// Match entry subsections: ### Reason
+	handoffSubsectionPattern = regexp.MustCompile(`^###\s+(.+?)\s*$`)
+)
+
+// ParseHandoff parses HANDOFF.md. Both the structured format written by
+// rwatch and the short "- Task / - See log" entries of ralph-loop are read.
+func ParseHandoff(filename string) (*Handoff, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Handoff{}
+	var entry *HandoffEntry
+	subsection := ""
+	var reason []string
+
+	flush := func() {
+		if entry == nil {
+			return
+		}
+		if len(reason) > 0 {
+			entry.Reason = strings.TrimSpace(strings.Join(reason, "\n"))
+		}
+		entry.Notes = trimBlankLines(entry.Notes)
+		if entry.Count == 0 {
+			entry.Count = 1
+		}
+		h.Entries = append(h.Entries, *entry)
+		entry = nil
+		reason = nil
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for i, line := range lines {
+		if matches := handoffHeaderPattern.FindStringSubmatch(line); matches != nil {
+			flush()
+			entry = &HandoffEntry{Timestamp: matches[1], Line: i + 1}
+			subsection = ""
+			continue
+		}
+
+		if entry == nil {
+			h.Preamble = append(h.Preamble, line)
+			continue
+		}
+
+		if matches := handoffSubsectionPattern.FindStringSubmatch(line); matches != nil {
+			name := strings.ToLower(matches[1])
+			switch {
+			case name == "reason" || name == "attempted" || name == "files touched":
+				subsection = name
+				continue
+			case subsection == "reason":
+				// The agent's reason may have headings of its own; only the
+				// known subsections end it
+			default:
+				subsection = name
+				entry.Notes = append(entry.Notes, line)
+				continue
+			}
+		}
+
+		switch subsection {
+		case "reason":
+			reason = append(reason, line)
+			continue
+		case "attempted", "files touched":
+			if matches := detailPattern.FindStringSubmatch(line); matches != nil {
+				item := strings.Trim(strings.TrimSpace(matches[1]), "`")
+				if subsection == "attempted" {
+					entry.Attempted = append(entry.Attempted, item)
+				} else {
+					entry.Files = append(entry.Files, item)
+				}
+				continue
+			}
+		}
+
+		if subsection == "" {
+			if matches := handoffFieldPattern.FindStringSubmatch(line); matches != nil {
+				value := strings.TrimSpace(matches[2])
+				switch matches[1] {
+				case "Task":
+					entry.Task = value
+				case "Reason":
+					reason = append(reason, value)
+				case "See log", "Log":
+					entry.LogFile = value
+				case "Times blocked":
+					entry.Count, _ = strconv.Atoi(value)
+				}
+				continue
+			}
+		}
+
+		entry.Notes = append(entry.Notes, line)
+	}
+	flush()
+
+	h.Preamble = trimBlankLines(h.Preamble)
+	return h, nil
+}
+
+// Tasks returns the titles of the blocked tasks
+func (h *Handoff) Tasks() []string {
+	tasks := make([]string, 0, len(h.Entries))
+	for _, e := range h.Entries {
+		if e.Task != "" {
+			tasks = append(tasks, e.Task)
+		}
+	}
+	return tasks
+}
+
+// Find returns the entry for task, or nil
+func (h *Handoff) Find(task string) *HandoffEntry {
+	for i := range h.Entries {
+		if h.Entries[i].Task == task {
+			return &h.Entries[i]
+		}
+	}
+	return nil
+}
+
+// Record adds a blocked entry, merging it into an existing entry for the
+// same task: the latest reason and log win, steps and files accumulate, and
+// the block count goes up
+func (h *Handoff) Record(e HandoffEntry) {
+	existing := h.Find(e.Task)
+	if existing == nil {
+		if e.Count == 0 {
+			e.Count = 1
+		}
+		h.Entries = append(h.Entries, e)
+		return
+	}
+
+	existing.Timestamp = e.Timestamp
+	existing.Count++
+	if e.Reason != "" {
+		existing.Reason = e.Reason
+	}
+	if e.LogFile != "" {
+		existing.LogFile = e.LogFile
+	}
+	existing.Attempted = appendUnique(existing.Attempted, e.Attempted...)
+	existing.Files = appendUnique(existing.Files, e.Files...)
+}
+
+// Remove deletes the entry for task, reporting whether it existed
+func (h *Handoff) Remove(task string) bool {
+	for i := range h.Entries {
+		if h.Entries[i].Task == task {
+			h.Entries = append(h.Entries[:i], h.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// String renders HANDOFF.md
+func (h *Handoff) String() string {
+	var sb strings.Builder
+
+	preamble := h.Preamble
+	if len(preamble) == 0 {
+		preamble = []string{"# Handoff", "", "Tasks that need human attention."}
+	}
+	sb.WriteString(strings.Join(preamble, "\n") + "\n")
+
+	for _, e := range h.Entries {
+		sb.WriteString(fmt.Sprintf("\n## Blocked Task (%s)\n", e.Timestamp))
+		sb.WriteString("- Task: " + e.Task + "\n")
+		if e.Count > 1 {
+			sb.WriteString(fmt.Sprintf("- Times blocked: %d\n", e.Count))
+		}
+		if e.LogFile != "" {
+			sb.WriteString("- See log: " + e.LogFile + "\n")
+		}
+		if e.Reason != "" {
+			sb.WriteString("\n### Reason\n" + e.Reason + "\n")
+		}
+		if len(e.Attempted) > 0 {
+			sb.WriteString("\n### Attempted\n")
+			for _, step := range e.Attempted {
+				sb.WriteString("- `" + step + "`\n")
+			}
+		}
+		if len(e.Files) > 0 {
+			sb.WriteString("\n### Files Touched\n")
+			for _, file := range e.Files {
+				sb.WriteString("- " + file + "\n")
+			}
+		}
+		if len(e.Notes) > 0 {
+			sb.WriteString("\n" + strings.Join(e.Notes, "\n") + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// appendUnique appends items not already in list
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
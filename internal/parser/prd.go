@@ -9,16 +9,36 @@ import (
 
 // Task represents a task from PRD.md
 type Task struct {
-	Title    string
-	Complete bool
-	Line     int
+	Title     string
+	Complete  bool
+	Line      int
+	Indent    int      // Leading whitespace width; 0 for top-level tasks
+	Section   string   // Headings above the task, e.g. "Tasks › Phase 1" (document title excluded)
+	Details   []string // Lines nested under the task (sub-bullets, acceptance criteria), dedented
+	DependsOn []string // Tasks named in a nested "Depends on: A, B" line (titles, IDs or #N)
+	ID        string   // Stable identifier; set by structured PRD files
+	Priority  int      // 0 (highest) to 3; from a P0..P3 or !! marker in PRD.md
+	Tags      []string // Labels such as "backend"; from #backend markers in PRD.md
+	File      string   // PRD file the task was read from
 }
 
+// DefaultPriority is the priority of tasks that don't set one
+const DefaultPriority = 2
+
 var (
-	// Match incomplete tasks: - [ ] Task description
-	incompletePattern = regexp.MustCompile(`^[\s]*-\s*\[\s*\]\s*(.+)$`)
+	// Match incomplete tasks: - [ ] Task description. Only this exact form,
+	// which ralph-loop also reads; prd lint reports the variants.
+	incompletePattern = regexp.MustCompile(`^[\s]*- \[ \] (.+)$`)
 	// Match complete tasks: - [x] Task description or - [X] Task description
-	completePattern = regexp.MustCompile(`^[\s]*-\s*\[[xX]\]\s*(.+)$`)
+	completePattern = regexp.MustCompile(`^[\s]*- \[[xX]\] (.+)$`)
+	// Match a dependency line nested under a task: - Depends on: A, #12
+	dependsPattern = regexp.MustCompile(`(?i)^[-*+]?\s*depends on:\s*(.+)$`)
+	// Match markdown headings: ## Section
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	// Match a priority marker word in a task title: P1, [P1] or !! (P0)
+	priorityPattern = regexp.MustCompile(`^(?:\[?P([0-3])\]?|!!)$`)
+	// Match a tag marker word in a task title: #backend (but not #12)
+	tagPattern = regexp.MustCompile(`^#([A-Za-z][\w./-]*)$`)
 )
 
 // ParsePRD parses a PRD.md file and extracts tasks
@@ -30,31 +50,72 @@ func ParsePRD(filename string) ([]Task, error) {
 	defer file.Close()
 
 	var tasks []Task
+	var headings []string // Heading text by level (index 0 = h1)
+	var open []int        // Indexes of tasks still collecting detail lines
+	inFence := false      // Inside a ``` code block
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
 
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
+		indent := indentWidth(line)
+		blank := strings.TrimSpace(line) == ""
 
-		// Check for incomplete task
-		if matches := incompletePattern.FindStringSubmatch(line); matches != nil {
-			tasks = append(tasks, Task{
-				Title:    strings.TrimSpace(matches[1]),
-				Complete: false,
-				Line:     lineNum,
-			})
+		// Headings and task lines in code blocks are examples
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		fenced := inFence || fence
+		if fence {
+			inFence = !inFence
+		}
+
+		if matches := headingPattern.FindStringSubmatch(line); matches != nil && !fenced {
+			level := len(matches[1])
+			for len(headings) < level-1 {
+				headings = append(headings, "")
+			}
+			headings = append(headings[:level-1], matches[2])
+			open = nil
+			continue
+		}
+
+		// Lines indented deeper than an open task belong to it
+		if !blank {
+			for len(open) > 0 && indent <= tasks[open[len(open)-1]].Indent {
+				open = open[:len(open)-1]
+			}
+		}
+		for _, i := range open {
+			tasks[i].Details = append(tasks[i].Details, dedent(line, tasks[i].Indent))
+		}
+
+		if fenced {
 			continue
 		}
 
-		// Check for complete task
-		if matches := completePattern.FindStringSubmatch(line); matches != nil {
-			tasks = append(tasks, Task{
-				Title:    strings.TrimSpace(matches[1]),
-				Complete: true,
-				Line:     lineNum,
-			})
+		task := Task{Line: lineNum, Indent: indent, Section: sectionPath(headings), File: filename}
+		if matches := incompletePattern.FindStringSubmatch(line); matches != nil {
+			// Incomplete task
+			task.Title = strings.TrimSpace(matches[1])
+		} else if matches := completePattern.FindStringSubmatch(line); matches != nil {
+			// Complete task
+			task.Title = strings.TrimSpace(matches[1])
+			task.Complete = true
+		} else {
+			continue
 		}
+
+		_, markers := SplitMarkers(task.Title)
+		task.Priority = MarkerPriority(markers)
+		task.Tags = MarkerTags(markers)
+
+		tasks = append(tasks, task)
+		open = append(open, len(tasks)-1)
+	}
+
+	for i := range tasks {
+		tasks[i].Details = trimBlankLines(tasks[i].Details)
+		tasks[i].DependsOn = dependencies(tasks[i].Details)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -64,6 +125,128 @@ func ParsePRD(filename string) ([]Task, error) {
 	return tasks, nil
 }
 
+// SplitMarkers separates the priority (P0..P3, or !! for P0) and #tag words
+// at the end of a task title from the text before them. Markers must follow
+// the text, so a title that merely mentions P1 or #42 keeps it; a trailing
+// ⛔ note is kept with the text and doesn't hide the markers before it.
+func SplitMarkers(title string) (text string, markers []string) {
+	note := ""
+	if i := strings.Index(title, "⛔"); i >= 0 {
+		title, note = title[:i], title[i:]
+	}
+
+	words := strings.Fields(title)
+	start := len(words)
+	for start > 0 && (priorityPattern.MatchString(words[start-1]) || tagPattern.MatchString(words[start-1])) {
+		start--
+	}
+	if start < len(words) {
+		markers = words[start:]
+	}
+	return strings.Join(append(words[:start:start], strings.Fields(note)...), " "), markers
+}
+
+// MarkerPriority returns the priority set by markers, or DefaultPriority.
+// The first priority marker wins.
+func MarkerPriority(markers []string) int {
+	for _, m := range markers {
+		match := priorityPattern.FindStringSubmatch(m)
+		if match == nil {
+			continue
+		}
+		if match[1] == "" {
+			return 0 // !!
+		}
+		return int(match[1][0] - '0')
+	}
+	return DefaultPriority
+}
+
+// MarkerTags returns the tags named by markers, without the leading #
+func MarkerTags(markers []string) []string {
+	var tags []string
+	for _, m := range markers {
+		if match := tagPattern.FindStringSubmatch(m); match != nil {
+			tags = append(tags, match[1])
+		}
+	}
+	return tags
+}
+
+// dependencies collects the references from a task's "Depends on:" lines
+func dependencies(details []string) []string {
+	var refs []string
+	for _, line := range details {
+		match := dependsPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		for _, ref := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ';' }) {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// indentWidth measures leading whitespace, counting a tab as four spaces
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// dedent removes up to n columns of leading whitespace plus the two-column
+// list indent, so details read as a list relative to their task
+func dedent(line string, n int) string {
+	n += 2
+	for n > 0 && len(line) > 0 {
+		switch line[0] {
+		case ' ':
+			n--
+		case '\t':
+			n -= 4
+		default:
+			return line
+		}
+		line = line[1:]
+	}
+	return line
+}
+
+// sectionPath joins the headings above a task, skipping the document title
+func sectionPath(headings []string) string {
+	var parts []string
+	for i, h := range headings {
+		if i == 0 || h == "" {
+			continue
+		}
+		parts = append(parts, h)
+	}
+	return strings.Join(parts, " › ")
+}
+
+// trimBlankLines drops leading and trailing blank lines
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
 // CountCompleted returns the number of completed tasks
 func CountCompleted(tasks []Task) int {
 	count := 0
@@ -7,18 +7,37 @@ import (
 	"strings"
 )
 
-// ProgressEntry represents an entry in progress.txt
+// ProgressEntry represents an entry in progress.txt. Entries are written by
+// the agent (free-form details) or by the orchestrator, one per iteration,
+// with key/value details:
+//
+//	[2024-01-15 14:32] Completed: Add login form
+//	- iteration: 4
+//	- status: complete
+//	- duration: 3m12s
+//	- commits: 1a2b3c4, 5d6e7f8
+//	- tools: Bash 12, Edit 5, Read 20
 type ProgressEntry struct {
 	Timestamp string
 	Title     string
 	Details   []string
+	Fields    map[string]string // "key: value" details, keys lower-cased
+}
+
+// Recorded reports whether the orchestrator wrote the entry
+func (e ProgressEntry) Recorded() bool {
+	_, ok := e.Fields["iteration"]
+	return ok
 }
 
 var (
-	// Match timestamp header: [2024-01-15 14:32] Completed: Task description
-	timestampPattern = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2}\s+\d{2}:\d{2})\]\s*(.+)$`)
-	// Match detail lines: - some detail
-	detailPattern = regexp.MustCompile(`^[\s]*-\s*(.+)$`)
+	// Match timestamp header: [2024-01-15 14:32] Completed: Task description.
+	// Seconds and a "T" separator are accepted too.
+	timestampPattern = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2}[\sT]+\d{2}:\d{2})(?::\d{2})?\]\s*(.+)$`)
+	// Match detail lines: - some detail (or * / +)
+	detailPattern = regexp.MustCompile(`^[\s]*[-*+]\s*(.+)$`)
+	// Match key/value details: status: complete
+	fieldPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z_ ]{0,23}):\s+(.+)$`)
 )
 
 // ParseProgress parses progress.txt and extracts entries
@@ -52,17 +71,24 @@ func ParseProgress(filename string) ([]ProgressEntry, error) {
 				entries = append(entries, *currentEntry)
 			}
 			currentEntry = &ProgressEntry{
-				Timestamp: matches[1],
+				Timestamp: strings.Replace(matches[1], "T", " ", 1),
 				Title:     matches[2],
 				Details:   []string{},
+				Fields:    map[string]string{},
 			}
 			continue
 		}
 
-		// Check for detail line
+		// Anything else under a header is a detail; lines without a list
+		// marker are kept as written
 		if currentEntry != nil {
+			detail := strings.TrimSpace(line)
 			if matches := detailPattern.FindStringSubmatch(line); matches != nil {
-				currentEntry.Details = append(currentEntry.Details, matches[1])
+				detail = matches[1]
+			}
+			currentEntry.Details = append(currentEntry.Details, detail)
+			if matches := fieldPattern.FindStringSubmatch(detail); matches != nil {
+				currentEntry.Fields[strings.ToLower(matches[1])] = matches[2]
 			}
 		}
 	}
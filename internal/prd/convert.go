@@ -0,0 +1,203 @@
+package prd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/fsutil"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+)
+
+// h1Pattern matches the document title of a PRD.md
+var h1Pattern = regexp.MustCompile(`(?m)^#\s+(.+?)\s*$`)
+
+// FromMarkdown builds a plan from the top-level tasks of a PRD.md. Nested
+// lines become acceptance criteria and "Depends on:" references are turned
+// into task IDs. Text outside tasks, apart from the title and section
+// headings, is not carried over. Warnings describe anything changed.
+func FromMarkdown(path string) (*Plan, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	tasks, err := parser.ParsePRD(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan := &Plan{}
+	if m := h1Pattern.FindSubmatch(data); m != nil {
+		plan.Title = string(m[1])
+	}
+
+	var top []parser.Task
+	for _, t := range tasks {
+		if t.Indent == 0 {
+			top = append(top, t)
+		}
+	}
+
+	var warnings []string
+	for _, t := range top {
+		pt := PlanTask{
+			Title:   NormalizeTitle(t.Title),
+			Owner:   OwnerAI,
+			Status:  StatusTodo,
+			Section: t.Section,
+			Tags:    t.Tags,
+		}
+		if t.Priority != parser.DefaultPriority {
+			pt.Priority = FormatPriority(t.Priority)
+		}
+		pt.ID = plan.uniqueID(slug(pt.Title))
+
+		switch {
+		case strings.Contains(t.Title, MarkerHuman):
+			pt.Owner = OwnerHuman
+		case !strings.Contains(t.Title, MarkerAI):
+			warnings = append(warnings, fmt.Sprintf("line %d: %q has no owner marker; converted as ai", t.Line, pt.Title))
+		}
+
+		switch {
+		case t.Complete:
+			pt.Status = StatusDone
+		case strings.Contains(t.Title, MarkerBlocked):
+			pt.Status = StatusBlocked
+			pt.Note = strings.TrimSpace(t.Title[strings.Index(t.Title, MarkerBlocked)+len(MarkerBlocked):])
+		}
+
+		for _, d := range t.Details {
+			d = strings.TrimSpace(d)
+			if d == "" || dependsLinePattern.MatchString(d) {
+				continue
+			}
+			pt.Acceptance = append(pt.Acceptance, strings.TrimSpace(listMarkerPattern.ReplaceAllString(d, "")))
+		}
+
+		plan.Tasks = append(plan.Tasks, pt)
+	}
+
+	// Dependencies refer to IDs once every task has one
+	for i, t := range top {
+		for _, ref := range t.DependsOn {
+			if j := FindTask(top, ref); j >= 0 {
+				ref = plan.Tasks[j].ID
+			} else {
+				warnings = append(warnings, fmt.Sprintf("line %d: dependency %q is not a task; kept as written", t.Line, ref))
+			}
+			plan.Tasks[i].DependsOn = append(plan.Tasks[i].DependsOn, ref)
+		}
+	}
+
+	return plan, warnings, nil
+}
+
+var (
+	// dependsLinePattern matches a "Depends on:" detail line
+	dependsLinePattern = regexp.MustCompile(`(?i)^[-*+]?\s*depends on:`)
+	// listMarkerPattern matches a leading list marker
+	listMarkerPattern = regexp.MustCompile(`^([-*+]|\d+[.)])\s+`)
+)
+
+// Markdown renders the plan as a PRD.md. Sections become headings,
+// dependencies are written as "Depends on:" lines naming task titles, and
+// priority and tags as markers after the title. Warnings list what Markdown
+// can't express.
+func (p *Plan) Markdown() (string, []string) {
+	var sb strings.Builder
+	var warnings []string
+
+	title := p.Title
+	if title == "" {
+		title = "PRD"
+	}
+	sb.WriteString("# " + title + "\n\n")
+	sb.WriteString("> Legend: 🤖 = AI task | 🧑 = Human task\n")
+
+	var current []string // Section path written so far
+	for i, pt := range p.Tasks {
+		var path []string
+		if pt.Section != "" {
+			path = strings.Split(pt.Section, " › ")
+		}
+		if i == 0 || pt.Section != strings.Join(current, " › ") {
+			common := 0
+			for common < len(path) && common < len(current) && path[common] == current[common] {
+				common++
+			}
+			sb.WriteString("\n")
+			for level := common; level < len(path); level++ {
+				sb.WriteString(strings.Repeat("#", level+2) + " " + path[level] + "\n")
+			}
+			current = path
+		}
+
+		t := taskLine{title: pt.Title, owner: MarkerAI}
+		t.markers = pt.markers()
+		for _, tag := range pt.Tags {
+			if _, ok := tagMarker(tag); !ok {
+				warnings = append(warnings, fmt.Sprintf("%q: tag %q can't be written as a #tag marker and is not kept", pt.Title, tag))
+			}
+		}
+		if pt.Owner == OwnerHuman {
+			t.owner = MarkerHuman
+		}
+		switch pt.Status {
+		case StatusDone:
+			t.done = true
+		case StatusBlocked:
+			t.note = strings.TrimSpace(MarkerBlocked + " " + pt.Note)
+		}
+		sb.WriteString(t.String() + "\n")
+
+		for _, a := range pt.Acceptance {
+			sb.WriteString("  - " + a + "\n")
+		}
+		if len(pt.DependsOn) > 0 {
+			refs := make([]string, len(pt.DependsOn))
+			for j, ref := range pt.DependsOn {
+				refs[j] = ref
+				for _, other := range p.Tasks {
+					if other.ID != "" && strings.EqualFold(other.ID, ref) {
+						refs[j] = other.Title
+					}
+				}
+			}
+			sb.WriteString("  - Depends on: " + strings.Join(refs, ", ") + "\n")
+		}
+	}
+
+	return sb.String(), warnings
+}
+
+// Convert reads the plan at from and writes it to to, each in the format
+// given by its extension (.md, .yaml/.yml or .json)
+func Convert(from, to string) ([]string, error) {
+	var plan *Plan
+	var warnings []string
+	var err error
+	if IsStructured(from) {
+		plan, err = LoadPlan(from)
+	} else {
+		plan, warnings, err = FromMarkdown(from)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	if IsStructured(to) {
+		out, err = plan.Encode(to)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		md, more := plan.Markdown()
+		out = []byte(md)
+		warnings = append(warnings, more...)
+	}
+
+	return warnings, fsutil.WriteAtomic(to, out)
+}
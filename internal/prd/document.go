@@ -0,0 +1,343 @@
+package prd
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/fsutil"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+)
+
+// File is the default PRD location
+const File = "PRD.md"
+
+// Owner markers
+const (
+	MarkerAI    = "🤖"
+	MarkerHuman = "🧑"
+	// MarkerBlocked is appended by the orchestrator to tasks it gave up on
+	MarkerBlocked = "⛔"
+)
+
+// ErrConflict is returned by Save when the file changed since it was loaded
+var ErrConflict = errors.New("the PRD was changed by someone else; reload and try again")
+
+// taskLinePattern splits a task line into indent, checkbox state and title
+var taskLinePattern = regexp.MustCompile(`^(\s*)-\s*\[([ xX])\]\s*(.+)$`)
+
+// blockedNotePattern matches the orchestrator's "⛔ blocked after N attempts" note
+var blockedNotePattern = regexp.MustCompile(`\s*` + MarkerBlocked + `.*$`)
+
+// Document is an editable PRD. Only task lines are ever rewritten; all other
+// Markdown is kept byte for byte.
+type Document struct {
+	Path  string
+	lines []string
+	hash  [sha256.Size]byte
+}
+
+// Load reads a PRD for editing
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{
+		Path:  path,
+		lines: strings.Split(string(data), "\n"),
+		hash:  sha256.Sum256(data),
+	}, nil
+}
+
+// Save writes the document atomically. It refuses with ErrConflict if the
+// file changed on disk since Load, so a running agent's edits are never
+// overwritten.
+func (d *Document) Save() error {
+	current, err := os.ReadFile(d.Path)
+	if err != nil {
+		return err
+	}
+	if sha256.Sum256(current) != d.hash {
+		return ErrConflict
+	}
+
+	data := []byte(strings.Join(d.lines, "\n"))
+	if err := fsutil.WriteAtomic(d.Path, data); err != nil {
+		return err
+	}
+	d.hash = sha256.Sum256(data)
+	return nil
+}
+
+// Edit loads the PRD at path, applies fn and saves it. If the file changes
+// between load and save, e.g. because the agent ticked a task, the edit is
+// re-applied to the new contents: fn finds its task by title, so unrelated
+// changes merge cleanly. ErrConflict is returned if the file keeps changing.
+func Edit(path string, fn func(d *Document) error) error {
+	for range maxEditAttempts {
+		d, err := Load(path)
+		if err != nil {
+			return err
+		}
+		if err := fn(d); err != nil {
+			return err
+		}
+		if err := d.Save(); err != ErrConflict {
+			return err
+		}
+	}
+	return ErrConflict
+}
+
+// maxEditAttempts bounds how often Edit re-applies a conflicting edit
+const maxEditAttempts = 3
+
+// Locate returns the index of the task line for title. hint is the task's
+// 1-based line number from the parser, tried first; if the file moved on,
+// the first task with the same normalised title outside code blocks is used.
+func (d *Document) Locate(title string, hint int) (int, error) {
+	want := NormalizeTitle(title)
+
+	if i := hint - 1; i >= 0 && i < len(d.lines) {
+		if m := taskLinePattern.FindStringSubmatch(d.lines[i]); m != nil && NormalizeTitle(m[3]) == want {
+			return i, nil
+		}
+	}
+	fenced := fencedLines(d.lines)
+	for i, line := range d.lines {
+		if fenced[i+1] {
+			continue
+		}
+		if m := taskLinePattern.FindStringSubmatch(line); m != nil && NormalizeTitle(m[3]) == want {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("task %q not found in %s", title, d.Path)
+}
+
+// SetComplete ticks or unticks the task at index i
+func (d *Document) SetComplete(i int, complete bool) {
+	m := taskLinePattern.FindStringSubmatch(d.lines[i])
+	box := "[ ]"
+	if complete {
+		box = "[x]"
+	}
+	d.lines[i] = m[1] + "- " + box + " " + m[3]
+}
+
+// Block appends the orchestrator's ⛔ note to the task at index i, so the
+// loop skips it
+func (d *Document) Block(i int, note string) {
+	d.lines[i] = strings.TrimRight(d.lines[i], " ") + " " + note
+}
+
+// Unblock clears the orchestrator's ⛔ note from the task at index i and
+// hands it to the agent, so the loop picks it up again
+func (d *Document) Unblock(i int) {
+	m := taskLinePattern.FindStringSubmatch(d.lines[i])
+	title := blockedNotePattern.ReplaceAllString(m[3], "")
+	title = strings.TrimSpace(strings.ReplaceAll(title, MarkerHuman, ""))
+	if !strings.Contains(title, MarkerAI) {
+		title = MarkerAI + " " + title
+	}
+	d.lines[i] = m[1] + "- [ ] " + strings.Join(strings.Fields(title), " ")
+}
+
+// SetTitle replaces the title of the task at index i, keeping its checkbox,
+// owner marker, blocked note and indentation. Priority and tag markers are
+// taken from title (see EditableTitle).
+func (d *Document) SetTitle(i int, title string) {
+	t := d.task(i)
+	t.title, t.markers = splitTitle(title)
+	d.lines[i] = t.String()
+}
+
+// ToggleOwner hands the task at index i from the agent to the human or back.
+// A task without a marker becomes the agent's.
+func (d *Document) ToggleOwner(i int) {
+	t := d.task(i)
+	if t.owner == MarkerAI {
+		t.owner = MarkerHuman
+	} else {
+		t.owner = MarkerAI
+	}
+	d.lines[i] = t.String()
+}
+
+// Add inserts a new open task owned by owner and returns its index. It goes
+// after the task at index after (and its nested lines) at the same
+// indentation, or after the last task in the file if after is negative.
+func (d *Document) Add(after int, title, owner string) int {
+	t := taskLine{owner: owner}
+	t.title, t.markers = splitTitle(title)
+
+	at := len(d.lines)
+	if after >= 0 {
+		t.prefix = d.task(after).prefix
+		_, at = d.block(after)
+	} else if last := d.lastTask(); last >= 0 {
+		_, at = d.block(last)
+	} else if at > 0 && d.lines[at-1] == "" {
+		at-- // Keep the file's trailing newline last
+	}
+
+	d.lines = append(d.lines[:at], append([]string{t.String()}, d.lines[at:]...)...)
+	return at
+}
+
+// Move swaps the task at index i, with its nested lines, with the previous
+// (delta < 0) or next (delta > 0) task at the same level, changing its
+// priority. It returns the task's new index. Tasks only move within their
+// list; headings and other Markdown stay where they are.
+func (d *Document) Move(i, delta int) (int, error) {
+	start, end := d.block(i)
+
+	if delta < 0 {
+		prev := d.sibling(start-1, d.task(i).indent)
+		if prev < 0 {
+			return i, errors.New("task is already first in its list")
+		}
+		d.swap(prev, start, end)
+		return prev, nil
+	}
+
+	if end >= len(d.lines) || !d.isTaskAt(end, d.task(i).indent) {
+		return i, errors.New("task is already last in its list")
+	}
+	_, nextEnd := d.block(end)
+	d.swap(start, end, nextEnd)
+	return start + (nextEnd - end), nil
+}
+
+// swap exchanges the adjacent line ranges [a, b) and [b, c)
+func (d *Document) swap(a, b, c int) {
+	moved := make([]string, 0, c-a)
+	moved = append(moved, d.lines[b:c]...)
+	moved = append(moved, d.lines[a:b]...)
+	copy(d.lines[a:c], moved)
+}
+
+// block returns the line range of the task at index i and its nested lines
+func (d *Document) block(i int) (int, int) {
+	indent := d.task(i).indent
+	end := i + 1
+	for end < len(d.lines) && d.lines[end] != "" && indentWidth(d.lines[end]) > indent {
+		end++
+	}
+	return i, end
+}
+
+// sibling walks back from index j over nested lines and returns the task at
+// indent that owns them, or -1 if there is none
+func (d *Document) sibling(j, indent int) int {
+	for j >= 0 && d.lines[j] != "" && indentWidth(d.lines[j]) > indent {
+		j--
+	}
+	if j >= 0 && d.isTaskAt(j, indent) {
+		return j
+	}
+	return -1
+}
+
+// isTaskAt reports whether line j is a task at the given indentation
+func (d *Document) isTaskAt(j, indent int) bool {
+	return taskLinePattern.MatchString(d.lines[j]) && indentWidth(d.lines[j]) == indent
+}
+
+// lastTask returns the index of the last task line, or -1
+func (d *Document) lastTask() int {
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		if taskLinePattern.MatchString(d.lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// task parses the task line at index i
+func (d *Document) task(i int) taskLine {
+	m := taskLinePattern.FindStringSubmatch(d.lines[i])
+	t := taskLine{indent: indentWidth(m[1]), prefix: m[1], done: m[2] != " "}
+
+	title := m[3]
+	if loc := blockedNotePattern.FindStringIndex(title); loc != nil {
+		t.note = strings.TrimSpace(title[loc[0]:])
+		title = title[:loc[0]]
+	}
+	switch {
+	case strings.Contains(title, MarkerAI):
+		t.owner = MarkerAI
+	case strings.Contains(title, MarkerHuman):
+		t.owner = MarkerHuman
+	}
+	t.title, t.markers = splitTitle(title)
+	return t
+}
+
+// taskLine is a parsed task line
+type taskLine struct {
+	indent  int
+	prefix  string // Leading whitespace as written
+	done    bool
+	owner   string
+	title   string
+	markers []string // Priority and tag markers, written after the title
+	note    string   // Trailing ⛔ note
+}
+
+// String formats the task line in the canonical "- [ ] 🤖 Title" form
+func (t taskLine) String() string {
+	box := "[ ]"
+	if t.done {
+		box = "[x]"
+	}
+	parts := []string{t.prefix + "- " + box}
+	for _, p := range []string{t.owner, t.title, strings.Join(t.markers, " "), t.note} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// indentWidth counts leading whitespace, a tab counting as four columns
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// NormalizeTitle reduces a task title to what identifies it: no owner,
+// priority or tag markers, no blocked note, single spaces
+func NormalizeTitle(title string) string {
+	text, _ := splitTitle(title)
+	return text
+}
+
+// EditableTitle is a task title as offered for editing: without owner
+// marker or blocked note, but with its priority and tags after the text
+func EditableTitle(title string) string {
+	text, markers := splitTitle(title)
+	return strings.TrimSpace(text + " " + strings.Join(markers, " "))
+}
+
+// splitTitle separates the priority and tag markers of a title from the
+// text that identifies it, dropping owner markers and the blocked note
+func splitTitle(title string) (string, []string) {
+	title = blockedNotePattern.ReplaceAllString(title, "")
+	title = strings.NewReplacer(MarkerAI, "", MarkerHuman, "").Replace(title)
+	return parser.SplitMarkers(title)
+}
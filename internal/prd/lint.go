@@ -0,0 +1,319 @@
+package prd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity of a lint problem
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The loop will misbehave
+	SeverityWarning Severity = "warning" // Probably a mistake
+)
+
+// Lint rules
+const (
+	RuleCheckbox     = "checkbox-syntax"    // Task-like line the orchestrator won't see
+	RuleMissingOwner = "missing-owner"      // Open task with neither 🤖 nor 🧑
+	RuleBothOwners   = "both-owners"        // Open task with both 🤖 and 🧑
+	RuleDuplicate    = "duplicate-task"     // Same title as an earlier task
+	RuleLongTitle    = "long-title"         // Title longer than the limit
+	RuleNestedTask   = "nested-task"        // Nested 🤖 task, which is never run
+	RuleDependency   = "unknown-dependency" // "Depends on:" names no task
+	RuleEmptySection = "empty-section"      // Heading with nothing under it
+	RuleInvalidField = "invalid-field"      // Bad owner, status or priority in a structured PRD
+)
+
+// DefaultMaxTitle is the title length, in characters, above which a task is
+// reported as too long to be a single atomic task
+const DefaultMaxTitle = 100
+
+// Problem is one lint finding
+type Problem struct {
+	Line     int      `json:"line"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+// LintOptions tune the linter
+type LintOptions struct {
+	MaxTitle int           // Maximum title length; 0 uses DefaultMaxTitle
+	Related  []parser.Task // Tasks of the project's other PRD files, which dependencies may name
+}
+
+var (
+	// lintTaskPattern matches anything that looks like a task: any bullet
+	// (or none), any spacing and an empty, blank or x box
+	lintTaskPattern = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])?(\s*)\[(\s*|[xX])\](\s*)(.*)$`)
+	// canonicalTaskPattern is the form the orchestrator reads
+	canonicalTaskPattern = regexp.MustCompile(`^\s*- \[[ xX]\] `)
+	// lintHeadingPattern matches Markdown headings
+	lintHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+)
+
+// Lint checks the PRD at path, Markdown or structured, for mistakes that
+// make the loop skip, repeat or misread tasks. Problems are returned in line
+// order.
+func Lint(path string, opts LintOptions) ([]Problem, error) {
+	if opts.MaxTitle <= 0 {
+		opts.MaxTitle = DefaultMaxTitle
+	}
+	if IsStructured(path) {
+		return lintPlan(path, opts)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := parser.ParsePRD(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	fenced := fencedLines(lines)
+	problems := lintCheckboxes(lines, fenced)
+	problems = append(problems, lintSections(lines, fenced)...)
+
+	problems = append(problems, lintTasks(tasks, opts)...)
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+// lintTasks applies the checks shared by all PRD formats. Task lines are
+// reported as they are, so callers map them to file lines.
+func lintTasks(tasks []parser.Task, opts LintOptions) []Problem {
+	var problems []Problem
+	add := func(line int, severity Severity, rule, format string, args ...any) {
+		problems = append(problems, Problem{Line: line, Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	firstLine := make(map[string]int)
+	for _, t := range tasks {
+		key := titleKey(t.Title)
+		if first, ok := firstLine[key]; ok {
+			add(t.Line, SeverityError, RuleDuplicate, "duplicate of the task on line %d", first)
+		} else {
+			firstLine[key] = t.Line
+		}
+	}
+
+	for _, t := range tasks {
+		title := NormalizeTitle(t.Title)
+		ai := strings.Contains(t.Title, MarkerAI)
+		human := strings.Contains(t.Title, MarkerHuman)
+
+		if t.Indent > 0 {
+			if ai && !t.Complete {
+				add(t.Line, SeverityWarning, RuleNestedTask, "nested 🤖 task is never run; only top-level tasks are picked")
+			}
+		} else if !t.Complete {
+			switch {
+			case ai && human:
+				add(t.Line, SeverityError, RuleBothOwners, "task is marked both 🤖 and 🧑")
+			case !ai && !human:
+				add(t.Line, SeverityError, RuleMissingOwner, "task has no 🤖 or 🧑 owner marker and is never run")
+			}
+		}
+
+		if n := utf8.RuneCountInString(title); n > opts.MaxTitle {
+			add(t.Line, SeverityWarning, RuleLongTitle, "title is %d characters (max %d); split the task or move detail into nested lines", n, opts.MaxTitle)
+		}
+
+		for _, ref := range t.DependsOn {
+			if FindTask(tasks, ref) < 0 && FindTask(opts.Related, ref) < 0 {
+				add(t.Line, SeverityError, RuleDependency, "depends on %q, which is not a task in the PRD", ref)
+			}
+		}
+	}
+
+	return problems
+}
+
+// lintCheckboxes reports task-like lines in a form the orchestrator ignores
+func lintCheckboxes(lines []string, fenced map[int]bool) []Problem {
+	var problems []Problem
+	for i, line := range lines {
+		match := lintTaskPattern.FindStringSubmatch(line)
+		if fenced[i+1] || match == nil || canonicalTaskPattern.MatchString(line) {
+			continue
+		}
+		if match[6] == "" {
+			continue // "[ ]" on its own is not a task
+		}
+
+		bullet, box := match[2], match[4]
+		var issue string
+		switch {
+		case bullet == "":
+			issue = "missing the \"- \" list marker"
+		case bullet != "-":
+			issue = fmt.Sprintf("uses %q instead of \"-\" as the list marker", bullet)
+		case match[3] != " ":
+			issue = "needs exactly one space between \"-\" and \"[ ]\""
+		case box != " " && box != "x" && box != "X":
+			issue = "the checkbox must be \"[ ]\" or \"[x]\""
+		default:
+			issue = "needs a space after the checkbox"
+		}
+
+		fixed := match[1] + "- [" + strings.TrimSpace(box) + "] " + match[6]
+		if strings.TrimSpace(box) == "" {
+			fixed = match[1] + "- [ ] " + match[6]
+		}
+		problems = append(problems, Problem{
+			Line:     i + 1,
+			Severity: SeverityError,
+			Rule:     RuleCheckbox,
+			Message:  fmt.Sprintf("task line %s; the loop won't see it (write %q)", issue, strings.TrimSpace(fixed)),
+		})
+	}
+	return problems
+}
+
+// lintSections reports headings with nothing under them before the next
+// heading of the same or a higher level
+func lintSections(lines []string, fenced map[int]bool) []Problem {
+	type heading struct {
+		line, level int
+		title       string
+		content     bool
+	}
+
+	var problems []Problem
+	var open []*heading
+	closeSections := func(level int) {
+		for len(open) > 0 && open[len(open)-1].level >= level {
+			h := open[len(open)-1]
+			open = open[:len(open)-1]
+			if !h.content && h.level > 1 {
+				problems = append(problems, Problem{
+					Line:     h.line,
+					Severity: SeverityWarning,
+					Rule:     RuleEmptySection,
+					Message:  fmt.Sprintf("section %q is empty", h.title),
+				})
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if match := lintHeadingPattern.FindStringSubmatch(line); match != nil && !fenced[i+1] {
+			level := len(match[1])
+			closeSections(level)
+			open = append(open, &heading{line: i + 1, level: level, title: match[2]})
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			for _, h := range open {
+				h.content = true
+			}
+		}
+	}
+	closeSections(1)
+	return problems
+}
+
+// fencedLines returns the 1-based numbers of lines inside ``` code blocks,
+// fences included
+func fencedLines(lines []string) map[int]bool {
+	fenced := make(map[int]bool)
+	inFence := false
+	for i, line := range lines {
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		if inFence || fence {
+			fenced[i+1] = true
+		}
+		if fence {
+			inFence = !inFence
+		}
+	}
+	return fenced
+}
+
+// lintPlan checks a prd.yaml or prd.json: field values, unique IDs and the
+// shared task checks. Problems carry the line each task starts on.
+func lintPlan(path string, opts LintOptions) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := decodePlan(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML too, so both can be mapped back to lines
+	lines := planLines(data)
+	tasks := plan.ParsedTasks()
+	for i := range tasks {
+		if i < len(lines) {
+			tasks[i].Line = lines[i]
+		}
+	}
+
+	var problems []Problem
+	add := func(line int, rule, format string, args ...any) {
+		problems = append(problems, Problem{Line: line, Severity: SeverityError, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	ids := make(map[string]int)
+	for i, pt := range plan.Tasks {
+		line := tasks[i].Line
+		if strings.TrimSpace(pt.Title) == "" {
+			add(line, RuleInvalidField, "task has no title")
+		}
+		if pt.Owner != "" && pt.Owner != OwnerAI && pt.Owner != OwnerHuman {
+			add(line, RuleInvalidField, "owner %q must be %q or %q", pt.Owner, OwnerAI, OwnerHuman)
+		}
+		if pt.Status != "" && pt.Status != StatusTodo && pt.Status != StatusDone && pt.Status != StatusBlocked {
+			add(line, RuleInvalidField, "status %q must be %q, %q or %q", pt.Status, StatusTodo, StatusDone, StatusBlocked)
+		}
+		if pt.Priority != "" && !priorityPattern.MatchString(pt.Priority) {
+			add(line, RuleInvalidField, "priority %q must be P0 to P3", pt.Priority)
+		}
+		if pt.ID != "" {
+			if first, ok := ids[pt.ID]; ok {
+				add(line, RuleDuplicate, "id %q is already used by the task on line %d", pt.ID, first)
+			} else {
+				ids[pt.ID] = line
+			}
+		}
+	}
+
+	problems = append(problems, lintTasks(tasks, opts)...)
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+// planLines returns the line each entry of a plan's tasks list starts on
+func planLines(data []byte) []int {
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "tasks" {
+			continue
+		}
+		var lines []int
+		for _, item := range root.Content[i+1].Content {
+			lines = append(lines, item.Line)
+		}
+		return lines
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package prd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/parser"
+)
+
+// Epic orders for projects with several PRD files
+const (
+	EpicOrderSequential = "sequential" // Finish each file's tasks before the next file's
+	EpicOrderPriority   = "priority"   // Take the highest-priority task from any file
+)
+
+// Project is the set of PRD files a run works through. Each file is an epic,
+// named after the file (prd/auth.md is "auth").
+type Project struct {
+	Patterns []string // Paths or globs, e.g. "prd/*.md"; none means Discover()
+	Order    string   // EpicOrderSequential (default) or EpicOrderPriority
+}
+
+// Files expands the patterns in order; files matched by a glob are sorted by
+// name and a file matched twice is used once. Without patterns it returns
+// the discovered PRD, which may not exist yet.
+func (p Project) Files() ([]string, error) {
+	if len(p.Patterns) == 0 {
+		return []string{Discover().Path()}, nil
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, pattern := range p.Patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PRD pattern %q: %w", pattern, err)
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no PRD files match %s: %w", strings.Join(p.Patterns, ", "), os.ErrNotExist)
+	}
+	return files, nil
+}
+
+// Multi reports whether the project is configured with its own PRD files
+// rather than the single discovered one
+func (p Project) Multi() bool {
+	return len(p.Patterns) > 0
+}
+
+// Tasks returns the tasks of every file, epic by epic, each with File set
+func (p Project) Tasks() ([]parser.Task, error) {
+	files, err := p.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []parser.Task
+	for _, file := range files {
+		epic, err := Open(file).Tasks()
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, epic...)
+	}
+	return tasks, nil
+}
+
+// Source returns the task source holding task, falling back to the first
+// PRD file for tasks that don't record one
+func (p Project) Source(task parser.Task) TaskSource {
+	if task.File != "" {
+		return Open(task.File)
+	}
+	if files, err := p.Files(); err == nil {
+		return Open(files[0])
+	}
+	return Discover()
+}
+
+// EpicName names the epic a PRD file holds: its file name without extension
+func EpicName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,63 @@
+package prd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/parser"
+)
+
+// Selection limits a run to some of the PRD's tasks, e.g. only backend
+// tasks. The zero value selects every task.
+type Selection struct {
+	OnlyTags []string // Only tasks with one of these tags; none means any
+	SkipTags []string // Never tasks with one of these tags
+	Priority string   // Only tasks of this priority or higher, e.g. "P1"; empty means any
+}
+
+// Validate checks the priority is one of P0 to P3
+func (s Selection) Validate() error {
+	if s.Priority != "" && !priorityPattern.MatchString(s.Priority) {
+		return fmt.Errorf("priority %q must be P0 to P3", s.Priority)
+	}
+	return nil
+}
+
+// Match reports whether task is selected
+func (s Selection) Match(task parser.Task) bool {
+	if s.Priority != "" && task.Priority > ParsePriority(s.Priority) {
+		return false
+	}
+	if hasTag(task, s.SkipTags) {
+		return false
+	}
+	return len(s.OnlyTags) == 0 || hasTag(task, s.OnlyTags)
+}
+
+// String describes the selection as flags, e.g. "--only-tags backend"
+func (s Selection) String() string {
+	var parts []string
+	if len(s.OnlyTags) > 0 {
+		parts = append(parts, "--only-tags "+strings.Join(s.OnlyTags, ","))
+	}
+	if len(s.SkipTags) > 0 {
+		parts = append(parts, "--skip-tags "+strings.Join(s.SkipTags, ","))
+	}
+	if s.Priority != "" {
+		parts = append(parts, "--priority "+FormatPriority(ParsePriority(s.Priority)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// hasTag reports whether task has any of tags, ignoring case and a leading #
+func hasTag(task parser.Task, tags []string) bool {
+	for _, want := range tags {
+		want = strings.TrimPrefix(strings.TrimSpace(want), "#")
+		for _, tag := range task.Tags {
+			if strings.EqualFold(strings.TrimPrefix(tag, "#"), want) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package prd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/issues"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+)
+
+// TaskSource is a plan file: PRD.md, or a structured prd.yaml/prd.json.
+// The orchestrator reads tasks from it and the TUI edits it, without
+// caring which format it is.
+type TaskSource interface {
+	// Path is the file the tasks live in
+	Path() string
+	// Tasks returns all tasks in file order. Titles carry the 🤖/🧑 owner
+	// marker and ⛔ note as they would in PRD.md.
+	Tasks() ([]parser.Task, error)
+	// Edit applies fn to the file and saves it, re-applying fn if the file
+	// changed in the meantime
+	Edit(fn func(e Editor) error) error
+}
+
+// Editor changes a loaded plan. Tasks are addressed by the index Locate
+// returns; indexes are only valid within one Edit.
+type Editor interface {
+	Locate(title string, hint int) (int, error)
+	SetComplete(i int, complete bool)
+	SetTitle(i int, title string)
+	ToggleOwner(i int)
+	Block(i int, note string)
+	Unblock(i int)
+	Add(after int, title, owner string) int
+	Move(i, delta int) (int, error)
+}
+
+// Candidates are the plan files looked for, in order of preference
+var Candidates = []string{File, "prd.yaml", "prd.yml", "prd.json"}
+
+// Discover returns the project's plan: the first of Candidates that exists,
+// or PRD.md (which the agent is asked to create) if there is none
+func Discover() TaskSource {
+	for _, path := range Candidates {
+		if _, err := os.Stat(path); err == nil {
+			return Open(path)
+		}
+	}
+	return Open(File)
+}
+
+// Open returns the task source for path, chosen by file extension
+func Open(path string) TaskSource {
+	if IsStructured(path) {
+		return &structuredSource{path: path}
+	}
+	return &markdownSource{path: path}
+}
+
+// IsStructured reports whether path names a YAML or JSON plan
+func IsStructured(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// markdownSource is a PRD.md-style checkbox list
+type markdownSource struct {
+	path string
+}
+
+func (s *markdownSource) Path() string { return s.path }
+
+func (s *markdownSource) Tasks() ([]parser.Task, error) {
+	return parser.ParsePRD(s.path)
+}
+
+func (s *markdownSource) Edit(fn func(e Editor) error) error {
+	return Edit(s.path, func(d *Document) error { return fn(d) })
+}
+
+// FindTask returns the index of the task ref points to, or -1. A reference
+// is a task ID, an issue number ("#12") or a title; owner markers, issue
+// suffixes and case are ignored.
+func FindTask(tasks []parser.Task, ref string) int {
+	ref = strings.TrimSpace(ref)
+	for i, t := range tasks {
+		if t.ID != "" && strings.EqualFold(t.ID, ref) {
+			return i
+		}
+	}
+	if strings.HasPrefix(ref, "#") {
+		for i, t := range tasks {
+			if n, ok := issues.TaskIssue(t.Title); ok && "#"+strconv.Itoa(n) == ref {
+				return i
+			}
+		}
+	}
+	want := titleKey(ref)
+	for i, t := range tasks {
+		if titleKey(t.Title) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// titleKey is the case-insensitive identity of a title
+func titleKey(title string) string {
+	return strings.ToLower(NormalizeTitle(issues.StripTaskIssue(title)))
+}
@@ -0,0 +1,358 @@
+package prd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xaelophone/ralph-setup/internal/fsutil"
+	"github.com/xaelophone/ralph-setup/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// Owners and statuses of structured plan tasks
+const (
+	OwnerAI    = "ai"
+	OwnerHuman = "human"
+
+	StatusTodo    = "todo"
+	StatusDone    = "done"
+	StatusBlocked = "blocked"
+)
+
+// Plan is a structured PRD, stored as prd.yaml or prd.json
+type Plan struct {
+	Title string     `yaml:"title,omitempty" json:"title,omitempty"`
+	Tasks []PlanTask `yaml:"tasks" json:"tasks"`
+}
+
+// PlanTask is one task of a structured plan
+type PlanTask struct {
+	ID         string   `yaml:"id,omitempty" json:"id,omitempty"`
+	Title      string   `yaml:"title" json:"title"`
+	Owner      string   `yaml:"owner,omitempty" json:"owner,omitempty"`           // ai (default) or human
+	Priority   string   `yaml:"priority,omitempty" json:"priority,omitempty"`     // P0 (highest) to P3; P2 if unset
+	Status     string   `yaml:"status,omitempty" json:"status,omitempty"`         // todo (default), done or blocked
+	Section    string   `yaml:"section,omitempty" json:"section,omitempty"`       // Heading path, e.g. "Phase 1"
+	DependsOn  []string `yaml:"depends_on,omitempty" json:"depends_on,omitempty"` // IDs or titles
+	Tags       []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Acceptance []string `yaml:"acceptance,omitempty" json:"acceptance,omitempty"`
+	Note       string   `yaml:"note,omitempty" json:"note,omitempty"` // Why the task is blocked
+}
+
+// priorityPattern matches "P0".."P3" or a bare digit
+var priorityPattern = regexp.MustCompile(`^[pP]?([0-3])$`)
+
+// ParsePriority converts a priority such as "P1" to 0-3, using
+// parser.DefaultPriority for empty or unknown values
+func ParsePriority(s string) int {
+	if m := priorityPattern.FindStringSubmatch(strings.TrimSpace(s)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return parser.DefaultPriority
+}
+
+// FormatPriority is the inverse of ParsePriority
+func FormatPriority(p int) string {
+	return "P" + strconv.Itoa(p)
+}
+
+// LoadPlan reads a structured plan
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodePlan(path, data)
+}
+
+// decodePlan parses YAML or JSON by file extension
+func decodePlan(path string, data []byte) (*Plan, error) {
+	var plan Plan
+	var err error
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &plan)
+	} else {
+		err = yaml.Unmarshal(data, &plan)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &plan, nil
+}
+
+// Encode formats the plan as YAML or JSON, chosen by path's extension
+func (p *Plan) Encode(path string) ([]byte, error) {
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		data, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParsedTasks converts the plan to tasks shaped like PRD.md's, so the loop
+// and TUI treat both formats alike. Line is the task's 1-based position.
+func (p *Plan) ParsedTasks() []parser.Task {
+	tasks := make([]parser.Task, 0, len(p.Tasks))
+	for i, pt := range p.Tasks {
+		marker := MarkerAI
+		if pt.Owner == OwnerHuman {
+			marker = MarkerHuman
+		}
+		title := marker + " " + pt.Title
+		if markers := pt.markers(); len(markers) > 0 {
+			title += " " + strings.Join(markers, " ")
+		}
+		if pt.Status == StatusBlocked {
+			note := pt.Note
+			if note == "" {
+				note = "blocked"
+			}
+			title += " " + MarkerBlocked + " " + note
+		}
+
+		var details []string
+		for _, a := range pt.Acceptance {
+			details = append(details, "- "+a)
+		}
+
+		tasks = append(tasks, parser.Task{
+			Title:     title,
+			Complete:  pt.Status == StatusDone,
+			Line:      i + 1,
+			Section:   pt.Section,
+			Details:   details,
+			DependsOn: pt.DependsOn,
+			ID:        pt.ID,
+			Priority:  ParsePriority(pt.Priority),
+			Tags:      pt.Tags,
+		})
+	}
+	return tasks
+}
+
+// Locate returns the index of the task titled title. hint is its 1-based
+// position, tried first.
+func (p *Plan) Locate(title string, hint int) (int, error) {
+	want := NormalizeTitle(title)
+	if i := hint - 1; i >= 0 && i < len(p.Tasks) && NormalizeTitle(p.Tasks[i].Title) == want {
+		return i, nil
+	}
+	for i, t := range p.Tasks {
+		if NormalizeTitle(t.Title) == want {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("task %q not found", title)
+}
+
+// SetComplete marks the task at index i done, or back to todo
+func (p *Plan) SetComplete(i int, complete bool) {
+	if complete {
+		p.Tasks[i].Status = StatusDone
+	} else {
+		p.Tasks[i].Status = StatusTodo
+	}
+	p.Tasks[i].Note = ""
+}
+
+// SetTitle renames the task at index i, taking its priority and tags from
+// any markers in title
+func (p *Plan) SetTitle(i int, title string) {
+	p.Tasks[i].setTitle(title)
+}
+
+// ToggleOwner hands the task at index i between the agent and the human
+func (p *Plan) ToggleOwner(i int) {
+	if p.Tasks[i].Owner == OwnerHuman {
+		p.Tasks[i].Owner = OwnerAI
+	} else {
+		p.Tasks[i].Owner = OwnerHuman
+	}
+}
+
+// Block marks the task at index i blocked with note
+func (p *Plan) Block(i int, note string) {
+	p.Tasks[i].Status = StatusBlocked
+	p.Tasks[i].Note = strings.TrimSpace(strings.TrimPrefix(note, MarkerBlocked))
+}
+
+// Unblock hands the task at index i back to the agent
+func (p *Plan) Unblock(i int) {
+	p.Tasks[i].Status = StatusTodo
+	p.Tasks[i].Owner = OwnerAI
+	p.Tasks[i].Note = ""
+}
+
+// Add inserts a new task after index after (at the end if negative) and
+// returns its index. It inherits the neighbour's section.
+func (p *Plan) Add(after int, title, owner string) int {
+	t := PlanTask{Owner: OwnerAI, Status: StatusTodo}
+	t.setTitle(title)
+	if owner == MarkerHuman {
+		t.Owner = OwnerHuman
+	}
+	t.ID = p.uniqueID(slug(t.Title))
+
+	at := len(p.Tasks)
+	if after >= 0 && after < len(p.Tasks) {
+		at = after + 1
+		t.Section = p.Tasks[after].Section
+	} else if at > 0 {
+		t.Section = p.Tasks[at-1].Section
+	}
+
+	p.Tasks = append(p.Tasks[:at], append([]PlanTask{t}, p.Tasks[at:]...)...)
+	return at
+}
+
+// Move swaps the task at index i with its previous (delta < 0) or next
+// neighbour and returns its new index
+func (p *Plan) Move(i, delta int) (int, error) {
+	j := i + 1
+	if delta < 0 {
+		j = i - 1
+	}
+	if j < 0 {
+		return i, fmt.Errorf("task is already first")
+	}
+	if j >= len(p.Tasks) {
+		return i, fmt.Errorf("task is already last")
+	}
+	p.Tasks[i], p.Tasks[j] = p.Tasks[j], p.Tasks[i]
+	return j, nil
+}
+
+// setTitle sets the title, priority and tags from a title with markers
+func (t *PlanTask) setTitle(title string) {
+	text, markers := splitTitle(title)
+	t.Title = text
+	t.Priority = ""
+	if p := parser.MarkerPriority(markers); p != parser.DefaultPriority {
+		t.Priority = FormatPriority(p)
+	}
+	t.Tags = parser.MarkerTags(markers)
+}
+
+// markers returns the task's priority and tags as PRD.md markers, e.g.
+// ["P1", "#backend"]. The default priority and tags that can't be written
+// as a marker are left out.
+func (t PlanTask) markers() []string {
+	var markers []string
+	if p := ParsePriority(t.Priority); p != parser.DefaultPriority {
+		markers = append(markers, FormatPriority(p))
+	}
+	for _, tag := range t.Tags {
+		if m, ok := tagMarker(tag); ok {
+			markers = append(markers, m)
+		}
+	}
+	return markers
+}
+
+// tagMarker formats tag as a #tag marker, reporting false if it isn't one
+// PRD.md can hold (it must start with a letter and have no spaces)
+func tagMarker(tag string) (string, bool) {
+	m := "#" + strings.TrimPrefix(tag, "#")
+	_, markers := parser.SplitMarkers(m)
+	return m, len(markers) == 1 && markers[0] == m
+}
+
+// uniqueID returns id, suffixed with -2, -3... if a task already uses it
+func (p *Plan) uniqueID(id string) string {
+	used := make(map[string]bool, len(p.Tasks))
+	for _, t := range p.Tasks {
+		used[t.ID] = true
+	}
+	candidate := id
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", id, n)
+	}
+	return candidate
+}
+
+// slugPattern matches runs of characters not allowed in IDs
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug turns a title into a short ID like "add-authentication"
+func slug(title string) string {
+	s := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(s) > 40 {
+		s = strings.TrimRight(s[:40], "-")
+	}
+	if s == "" {
+		s = "task"
+	}
+	return s
+}
+
+// structuredSource is a prd.yaml or prd.json plan
+type structuredSource struct {
+	path string
+}
+
+func (s *structuredSource) Path() string { return s.path }
+
+func (s *structuredSource) Tasks() ([]parser.Task, error) {
+	plan, err := LoadPlan(s.path)
+	if err != nil {
+		return nil, err
+	}
+	tasks := plan.ParsedTasks()
+	for i := range tasks {
+		tasks[i].File = s.path
+	}
+	return tasks, nil
+}
+
+// Edit applies fn like Edit does for Markdown. The whole file is rewritten,
+// so comments in YAML plans are not kept.
+func (s *structuredSource) Edit(fn func(e Editor) error) error {
+	for range maxEditAttempts {
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			return err
+		}
+		plan, err := decodePlan(s.path, data)
+		if err != nil {
+			return err
+		}
+		if err := fn(plan); err != nil {
+			return err
+		}
+		out, err := plan.Encode(s.path)
+		if err != nil {
+			return err
+		}
+
+		current, err := os.ReadFile(s.path)
+		if err != nil {
+			return err
+		}
+		if sha256.Sum256(current) != sha256.Sum256(data) {
+			continue
+		}
+		return fsutil.WriteAtomic(s.path, out)
+	}
+	return ErrConflict
+}
@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NotesFile holds notes from the human, injected into the next prompt
+const NotesFile = ".ralph/notes.md"
+
+// AddNote queues a note for the next prompt. Notes about a task are
+// prefixed with it so the agent knows what they refer to.
+func AddNote(task, note string) error {
+	note = strings.Join(strings.Fields(note), " ")
+	if task != "" {
+		note = "Re: " + task + " — " + note
+	}
+
+	if err := os.MkdirAll(filepath.Dir(NotesFile), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(NotesFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString("- " + note + "\n")
+	return err
+}
+
+// SendingFile holds the notes taken for a prompt until the prompt has been
+// handed to the CLI
+const SendingFile = NotesFile + ".sending"
+
+// ReadNotes returns the queued notes
+func ReadNotes() []string {
+	return readNotes(NotesFile)
+}
+
+// readNotes returns the notes in file, one per line
+func readNotes(file string) []string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	var notes []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if note := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- ")); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// TakeNotes returns the notes for the next prompt, moving the queue aside so
+// notes added meanwhile wait for the following one. Call NotesSent once the
+// prompt reaches the CLI; until then the same notes are taken again, so a
+// prompt that never ran doesn't lose them.
+func TakeNotes() []string {
+	if _, err := os.Stat(SendingFile); os.IsNotExist(err) {
+		os.Rename(NotesFile, SendingFile)
+	}
+	return readNotes(SendingFile)
+}
+
+// NotesSent discards the notes taken by TakeNotes, so each note is sent once
+func NotesSent() {
+	os.Remove(SendingFile)
+}
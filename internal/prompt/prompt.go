@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// TemplateFile is the project-specific prompt template
+const TemplateFile = ".ralph/prompt.tmpl"
+
+//go:embed default.tmpl
+var defaultTemplate string
+
+// Data is the context available to prompt templates
+type Data struct {
+	Iteration       int      // Loop iteration, starting at 1
+	Task            string   // Current task title, without the 🤖 marker
+	TaskFile        string   // PRD holding the tasks: PRD.md, prd.yaml or prd.json
+	CompleteHint    string   // How to mark a task done in TaskFile, e.g. "- [x]"
+	TaskContext     string   // Detail lines and section of the task in the PRD
+	RecentProgress  string   // Selected progress.txt entries
+	PreviousFailure string   // Digest of the last failed attempt at this task
+	Handoff         []string // Tasks recorded as blocked in HANDOFF.md
+	Notes           []string // Notes from the human, added in the rwatch inbox
+	GitStatus       string   // `git status --short` of the working tree
+}
+
+// Default returns the built-in template source
+func Default() string {
+	return defaultTemplate
+}
+
+// Source returns the template used for this project: .ralph/prompt.tmpl if
+// it exists, otherwise the built-in default. The second value names the source.
+func Source() (string, string, error) {
+	data, err := os.ReadFile(TemplateFile)
+	if os.IsNotExist(err) {
+		return defaultTemplate, "built-in default", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), TemplateFile, nil
+}
+
+// Render executes the project's template with data
+func Render(data Data) (string, error) {
+	src, name, err := Source()
+	if err != nil {
+		return "", err
+	}
+	return RenderSource(name, src, data)
+}
+
+// RenderSource executes a template source with data. Unknown fields are
+// reported as errors rather than rendered as "<no value>".
+func RenderSource(name, src string, data Data) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %w", name, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("rendering %s: %w", name, err)
+	}
+	return sb.String(), nil
+}
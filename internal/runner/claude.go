@@ -5,44 +5,49 @@ import (
 	"os"
 	"os/exec"
 	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/creack/pty"
+	"github.com/xaelophone/ralph-setup/internal/cli"
 )
 
-// Runner manages the Claude process
+// Runner manages the interactive CLI process of legacy mode
 type Runner struct {
-	args    []string
+	cli     cli.CLIRunner
 	program *tea.Program
 	cmd     *exec.Cmd
 	ptmx    *os.File
+	size    *pty.Winsize  // Terminal size, once the TUI knows it
+	read    chan struct{} // Closed when readOutput has drained the PTY
+	tokens  tokenScanner  // Guarded by mu
 	mu      sync.Mutex
 	running bool
 }
 
-// New creates a new Runner
-func New(args []string, program *tea.Program) *Runner {
+// New creates a Runner for the configured CLI backend
+func New(backend cli.CLIRunner, program *tea.Program) *Runner {
 	return &Runner{
-		args:    args,
+		cli:     backend,
 		program: program,
 	}
 }
 
-// OutputMsg is sent when Claude produces output
+// OutputMsg is sent when the CLI produces output
 type OutputMsg struct {
 	Content string
 }
 
-// StartedMsg is sent when Claude starts
+// StartedMsg is sent when the CLI starts
 type StartedMsg struct{}
 
-// StoppedMsg is sent when Claude stops
+// StoppedMsg is sent when the CLI stops
 type StoppedMsg struct {
 	ExitCode int
 	Error    error
 }
 
-// Start starts the Claude process with a PTY
+// Start starts the CLI's interactive session with a PTY
 func (r *Runner) Start() error {
 	r.mu.Lock()
 	if r.running {
@@ -50,19 +55,17 @@ func (r *Runner) Start() error {
 		return nil
 	}
 
-	// Find claude binary
-	claudePath, err := exec.LookPath("claude")
-	if err != nil {
+	// Build command; Err is set if the CLI isn't on PATH
+	r.cmd = r.cli.BuildInteractiveCommand("")
+	if err := r.cmd.Err; err != nil {
 		r.mu.Unlock()
 		r.program.Send(StoppedMsg{ExitCode: 1, Error: err})
 		return err
 	}
 
-	// Build command
-	r.cmd = exec.Command(claudePath, r.args...)
-
 	// Start with PTY for proper terminal emulation
-	r.ptmx, err = pty.Start(r.cmd)
+	var err error
+	r.ptmx, err = pty.StartWithSize(r.cmd, r.size)
 	if err != nil {
 		r.mu.Unlock()
 		r.program.Send(StoppedMsg{ExitCode: 1, Error: err})
@@ -70,17 +73,15 @@ func (r *Runner) Start() error {
 	}
 
 	r.running = true
+	r.read = make(chan struct{})
 	r.mu.Unlock()
 
-	// Notify that Claude has started
+	// Notify that the CLI has started
 	r.program.Send(StartedMsg{})
 
-	// Read output in goroutine
+	// Read output in goroutine; input comes from the TUI through SendInput
 	go r.readOutput()
 
-	// Handle stdin pass-through
-	go r.handleStdin()
-
 	// Wait for process to complete
 	go r.waitForCompletion()
 
@@ -89,6 +90,7 @@ func (r *Runner) Start() error {
 
 // readOutput reads from the PTY and sends to the program
 func (r *Runner) readOutput() {
+	defer close(r.read)
 	buf := make([]byte, 4096)
 	for {
 		n, err := r.ptmx.Read(buf)
@@ -101,22 +103,28 @@ func (r *Runner) readOutput() {
 		if n > 0 {
 			// Send output to the TUI
 			r.program.Send(OutputMsg{Content: string(buf[:n])})
+
+			r.mu.Lock()
+			token := r.tokens.scan(buf[:n])
+			r.mu.Unlock()
+			if token != "" {
+				r.program.Send(CompletionMsg{Blocked: token == cli.BlockedToken})
+			}
 		}
 	}
 }
 
-// handleStdin passes stdin to Claude
-func (r *Runner) handleStdin() {
-	// Read from original stdin and write to PTY
-	// Note: In a full TUI, we'd need a more sophisticated approach
-	// to handle keyboard input that shouldn't go to Claude
-	io.Copy(r.ptmx, os.Stdin)
-}
-
 // waitForCompletion waits for the process to finish
 func (r *Runner) waitForCompletion() {
 	err := r.cmd.Wait()
 
+	// Let the last output through before closing the PTY; give up if a
+	// leftover child still holds the terminal open
+	select {
+	case <-r.read:
+	case <-time.After(time.Second):
+	}
+
 	r.mu.Lock()
 	r.running = false
 	if r.ptmx != nil {
@@ -132,7 +140,7 @@ func (r *Runner) waitForCompletion() {
 	r.program.Send(StoppedMsg{ExitCode: exitCode, Error: err})
 }
 
-// Stop stops the Claude process
+// Stop stops the CLI process
 func (r *Runner) Stop() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -152,14 +160,27 @@ func (r *Runner) Stop() {
 	r.running = false
 }
 
-// IsRunning returns whether Claude is running
+// Resize sets the PTY's size, which the CLI redraws for on SIGWINCH. Before
+// Start it sets the size the CLI starts with.
+func (r *Runner) Resize(cols, rows int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.size = &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)}
+	if !r.running || r.ptmx == nil {
+		return nil
+	}
+	return pty.Setsize(r.ptmx, r.size)
+}
+
+// IsRunning returns whether the CLI is running
 func (r *Runner) IsRunning() bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	return r.running
 }
 
-// SendInput sends input to Claude
+// SendInput sends input to the CLI
 func (r *Runner) SendInput(input string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -168,6 +189,7 @@ func (r *Runner) SendInput(input string) error {
 		return nil
 	}
 
+	r.tokens.sent(input)
 	_, err := r.ptmx.WriteString(input)
 	return err
 }
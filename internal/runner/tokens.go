@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/xaelophone/ralph-setup/internal/cli"
+)
+
+// CompletionMsg is sent when the CLI outputs a completion or blocked token
+type CompletionMsg struct {
+	Blocked bool // <promise>BLOCKED</promise> rather than COMPLETE
+}
+
+// tokens are the completion tokens scanned for
+var tokens = []string{cli.CompletionToken, cli.BlockedToken}
+
+// maxEchoes bounds the input lines remembered for spotting echoed tokens
+const maxEchoes = 32
+
+// maxLine bounds the text kept for a line; full-screen CLIs may draw for a
+// long time without ending one
+const maxLine = 4096
+
+// Stripping states
+const (
+	stripText = iota
+	stripEsc
+	stripCSI
+	stripString // OSC, DCS and friends, up to BEL or ST
+	stripStringEsc
+)
+
+// echo is the text around a token in a line of input, as letters and digits
+type echo struct {
+	before, after string
+}
+
+// tokenScanner looks for completion tokens in raw PTY output. It strips
+// escape sequences, collecting plain text a line at a time so escapes and
+// tokens split across reads are handled, and reports a token at most once
+// per prompt submitted, since interactive CLIs redraw the screen.
+//
+// The prompt itself names the tokens and the CLI echoes what is typed, so a
+// token is ignored when the text before or after it on its line matches the
+// text around a token in a line of input. A token alone on a line of the
+// prompt can't be told from the CLI's own.
+type tokenScanner struct {
+	state  int
+	line   []rune // Plain text of the current output line
+	found  bool   // A token was reported since the last submitted prompt
+	echoes []echo
+	input  strings.Builder // Input typed since the last newline
+}
+
+// scan strips p and returns the token on any line it completes
+func (s *tokenScanner) scan(p []byte) (token string) {
+	for _, r := range string(p) {
+		switch s.state {
+		case stripText:
+			switch {
+			case r == 0x1b:
+				s.state = stripEsc
+			case r == '\n':
+				s.endLine(&token)
+			case r == '\r' || r == '\t':
+				s.line = append(s.line, ' ')
+			case r >= ' ' && r != 0x7f:
+				s.line = append(s.line, r)
+				if len(s.line) > maxLine {
+					s.endLine(&token)
+				}
+			}
+		case stripEsc:
+			switch r {
+			case '[':
+				s.state = stripCSI
+			case ']', 'P', 'X', '^', '_':
+				s.state = stripString
+			default:
+				s.state = stripText
+			}
+		case stripCSI:
+			if r < 0x40 || r > 0x7e {
+				continue
+			}
+			s.state = stripText
+			switch r {
+			case 'A', 'B', 'E', 'F', 'H', 'f', 'd', 'J':
+				// Moving to another line or clearing the screen ends this one
+				s.endLine(&token)
+			case 'C', 'G', 'X':
+				// Skipped or erased columns stand in for spaces
+				s.line = append(s.line, ' ')
+			}
+		case stripString:
+			switch r {
+			case 0x07:
+				s.state = stripText
+			case 0x1b:
+				s.state = stripStringEsc
+			}
+		case stripStringEsc:
+			s.state = stripText
+		}
+	}
+	return token
+}
+
+// endLine looks for a token in the line just finished and starts a new one
+func (s *tokenScanner) endLine(token *string) {
+	line := string(s.line)
+	s.line = s.line[:0]
+	if s.found || *token != "" {
+		return
+	}
+
+	for _, t := range tokens {
+		for offset := 0; ; {
+			i := strings.Index(line[offset:], t)
+			if i < 0 {
+				break
+			}
+			at := offset + i
+			offset = at + len(t)
+			if s.echoed(line[:at], line[offset:]) {
+				continue
+			}
+			s.found = true
+			*token = t
+			return
+		}
+	}
+}
+
+// echoed reports whether a token with before and after around it on its
+// line is the echo of input. Line wrapping may cut either short.
+func (s *tokenScanner) echoed(before, after string) bool {
+	b, a := lettersOf(before), lettersOf(after)
+	for _, e := range append(echoesIn(s.input.String()), s.echoes...) {
+		if b != "" && e.before != "" && (strings.HasSuffix(b, e.before) || strings.HasSuffix(e.before, b)) {
+			return true
+		}
+		if a != "" && e.after != "" && (strings.HasPrefix(a, e.after) || strings.HasPrefix(e.after, a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sent records input written to the CLI, skipping key and paste escape
+// sequences. Submitting a line (Enter) rearms the scanner for the response.
+func (s *tokenScanner) sent(input string) {
+	escape := false
+	for _, r := range input {
+		switch {
+		case r == 0x1b:
+			escape = true
+		case escape:
+			escape = r == '[' || r == 'O' || r < 0x40 || r > 0x7e
+		case r == '\r' || r == '\n':
+			s.echoes = append(s.echoes, echoesIn(s.input.String())...)
+			if len(s.echoes) > maxEchoes {
+				s.echoes = s.echoes[len(s.echoes)-maxEchoes:]
+			}
+			s.input.Reset()
+			if r == '\r' {
+				s.found = false
+			}
+		default:
+			s.input.WriteRune(r)
+		}
+	}
+}
+
+// echoesIn returns the text around each token in a line of input
+func echoesIn(line string) []echo {
+	var echoes []echo
+	for _, t := range tokens {
+		for offset := 0; ; {
+			i := strings.Index(line[offset:], t)
+			if i < 0 {
+				break
+			}
+			at := offset + i
+			offset = at + len(t)
+			echoes = append(echoes, echo{before: lettersOf(line[:at]), after: lettersOf(line[offset:])})
+		}
+	}
+	return echoes
+}
+
+// lettersOf keeps the letters and digits of s, so text compares equal however
+// the terminal wrapped, padded or decorated it
+func lettersOf(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
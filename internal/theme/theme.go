@@ -28,6 +28,8 @@ type Theme struct {
 	TaskComplete lipgloss.Style
 	TaskCurrent  lipgloss.Style
 	TaskPending  lipgloss.Style
+	TaskPriority lipgloss.Style
+	TaskTag      lipgloss.Style
 
 	// Progress
 	ProgressTime   lipgloss.Style
@@ -125,6 +127,13 @@ func Default() Theme {
 		TaskPending: lipgloss.NewStyle().
 			Foreground(colorMuted),
 
+		TaskPriority: lipgloss.NewStyle().
+			Foreground(colorWarning).
+			Bold(true),
+
+		TaskTag: lipgloss.NewStyle().
+			Foreground(colorSecondary),
+
 		// Progress
 		ProgressTime: lipgloss.NewStyle().
 			Foreground(colorMuted),
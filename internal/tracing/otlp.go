@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"strconv"
+	"time"
+)
+
+// OTLP/JSON wire types (opentelemetry-proto, JSON mapping). Only the fields
+// rwatch produces are modelled. Trace and span IDs are hex-encoded, and
+// 64-bit timestamps are encoded as strings, as the spec requires.
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []attribute `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type span struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []attribute `json:"attributes,omitempty"`
+	Status            spanStatus  `json:"status"`
+}
+
+type spanStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type attribute struct {
+	Key   string    `json:"key"`
+	Value attrValue `json:"value"`
+}
+
+type attrValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// Span kinds and status codes
+const (
+	spanKindInternal = 1
+
+	statusOK    = 1
+	statusError = 2
+)
+
+func stringAttr(key, value string) attribute {
+	return attribute{Key: key, Value: attrValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int) attribute {
+	s := strconv.Itoa(value)
+	return attribute{Key: key, Value: attrValue{IntValue: &s}}
+}
+
+func doubleAttr(key string, value float64) attribute {
+	return attribute{Key: key, Value: attrValue{DoubleValue: &value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
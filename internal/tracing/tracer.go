@@ -0,0 +1,340 @@
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xaelophone/ralph-setup/internal/orchestrator"
+)
+
+// Tracer exports each orchestrator session as a trace: a session span with
+// one child span per iteration, which in turn parents a span per tool call.
+// It implements orchestrator.Observer.
+//
+// The target is either a file path, rewritten after every iteration so it
+// always holds a complete trace, or an OTLP HTTP endpoint that receives
+// spans as they finish.
+type Tracer struct {
+	target   string
+	endpoint bool
+	backend  string
+	version  string
+	http     *http.Client
+
+	mu            sync.Mutex
+	program       *tea.Program // Receives export failures, if set
+	traceID       string
+	sessionSpanID string
+	spans         []span
+	pending       sync.WaitGroup
+}
+
+// New creates a tracer writing to target: a file path, or an http(s) URL of
+// an OTLP collector (/v1/traces is appended when the URL has no path)
+func New(target, backend, version string) (*Tracer, error) {
+	t := &Tracer{
+		target:  target,
+		backend: backend,
+		version: version,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trace endpoint: %w", err)
+		}
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/v1/traces"
+		}
+		t.target = u.String()
+		t.endpoint = true
+	}
+
+	return t, nil
+}
+
+// SetProgram reports export failures in the program's output pane. Without a
+// program they are dropped, since logging would draw over the TUI.
+func (t *Tracer) SetProgram(p *tea.Program) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.program = p
+}
+
+// IterationStarted starts the trace on the first iteration
+func (t *Tracer) IterationStarted(session *orchestrator.Session, task string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start(session)
+}
+
+// ToolFinished is a no-op; tool spans are built from the iteration result so
+// calls that never finished are included too
+func (t *Tracer) ToolFinished(session *orchestrator.Session, trace orchestrator.SubagentTrace) {}
+
+// IterationFinished records spans for the iteration and its tool calls
+func (t *Tracer) IterationFinished(session *orchestrator.Session, result orchestrator.IterationResult, consecutiveFailures int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start(session)
+
+	end := time.Now()
+	iter := span{
+		TraceID:           t.traceID,
+		SpanID:            newSpanID(),
+		ParentSpanID:      t.sessionSpanID,
+		Name:              fmt.Sprintf("iteration %d", result.Iteration),
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(end.Add(-result.Duration)),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes: []attribute{
+			intAttr("ralph.iteration", result.Iteration),
+			stringAttr("ralph.task", result.Task),
+			stringAttr("ralph.iteration.status", string(result.Status)),
+			stringAttr("ralph.log_file", result.LogFile),
+			intAttr("ralph.consecutive_failures", consecutiveFailures),
+		},
+		Status: spanStatus{Code: statusOK},
+	}
+	if result.Status != orchestrator.IterationStatusComplete {
+		iter.Status = spanStatus{Code: statusError, Message: string(result.Status)}
+	}
+
+	spans := []span{iter}
+	for _, trace := range result.Subagents {
+		spans = append(spans, t.toolSpan(iter.SpanID, trace, end))
+	}
+	t.spans = append(t.spans, spans...)
+
+	if t.endpoint {
+		t.post(spans, session)
+	} else {
+		t.writeFile(session, end)
+	}
+}
+
+// SessionEnded closes the session span and exports it
+func (t *Tracer) SessionEnded(session *orchestrator.Session) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start(session)
+
+	if t.endpoint {
+		t.post([]span{t.sessionSpan(session, time.Now())}, session)
+	} else {
+		t.writeFile(session, time.Now())
+	}
+}
+
+// Close waits up to timeout for spans still being sent to the endpoint
+func (t *Tracer) Close(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		t.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// start assigns the trace and session span IDs. The trace ID is the session
+// UUID, so a trace can be found from .ralph-session.json. Callers must hold t.mu.
+func (t *Tracer) start(session *orchestrator.Session) {
+	if t.traceID != "" {
+		return
+	}
+	t.traceID = strings.ReplaceAll(session.ID, "-", "")
+	if _, err := hex.DecodeString(t.traceID); err != nil || len(t.traceID) != 32 {
+		t.traceID = randomHex(16)
+	}
+	t.sessionSpanID = newSpanID()
+}
+
+// sessionSpan builds the root span, ending at end
+func (t *Tracer) sessionSpan(s *orchestrator.Session, end time.Time) span {
+	root := span{
+		TraceID:           t.traceID,
+		SpanID:            t.sessionSpanID,
+		Name:              "ralph session",
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(s.StartedAt),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes: []attribute{
+			stringAttr("ralph.session_id", s.ID),
+			stringAttr("ralph.session.status", string(s.Status)),
+			intAttr("ralph.iterations", s.Iteration),
+			intAttr("ralph.tasks_completed", s.TasksCompleted),
+			intAttr("ralph.tasks_remaining", s.TasksRemaining),
+			intAttr("ralph.tokens.input", s.InputTokens),
+			intAttr("ralph.tokens.output", s.OutputTokens),
+			doubleAttr("ralph.cost_usd", s.CostUSD),
+		},
+		Status: spanStatus{Code: statusOK},
+	}
+
+	switch s.Status {
+	case orchestrator.SessionStatusFailed, orchestrator.SessionStatusBudgetExhausted:
+		root.Status = spanStatus{Code: statusError, Message: string(s.Status)}
+	}
+	return root
+}
+
+// toolSpan builds a span for a tool call. Calls still running when the
+// iteration ended are closed at iterEnd and marked as errors.
+func (t *Tracer) toolSpan(parent string, trace orchestrator.SubagentTrace, iterEnd time.Time) span {
+	end := iterEnd
+	if trace.EndedAt != nil {
+		end = *trace.EndedAt
+	}
+
+	s := span{
+		TraceID:           t.traceID,
+		SpanID:            newSpanID(),
+		ParentSpanID:      parent,
+		Name:              trace.Type,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(trace.StartedAt),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes: []attribute{
+			stringAttr("tool.name", trace.Type),
+			stringAttr("tool.input", trace.Input),
+			stringAttr("tool.status", string(trace.Status)),
+		},
+		Status: spanStatus{Code: statusOK},
+	}
+
+	switch {
+	case trace.Status == orchestrator.SubagentStatusError:
+		s.Status = spanStatus{Code: statusError, Message: trace.Output}
+	case trace.EndedAt == nil:
+		s.Status = spanStatus{Code: statusError, Message: "unfinished"}
+	}
+	return s
+}
+
+// request wraps spans in an export request with the rwatch resource
+func (t *Tracer) request(session *orchestrator.Session, spans []span) exportRequest {
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []attribute{
+			stringAttr("service.name", "rwatch"),
+			stringAttr("service.version", t.version),
+			stringAttr("ralph.project", filepath.Base(session.WorkingDir)),
+			stringAttr("ralph.cli", t.backend),
+		}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: "github.com/xaelophone/ralph-setup/rwatch", Version: t.version},
+			Spans: spans,
+		}},
+	}}}
+}
+
+// writeFile rewrites the trace file with every span so far and the session
+// span ending at end. Callers must hold t.mu.
+func (t *Tracer) writeFile(session *orchestrator.Session, end time.Time) {
+	spans := append([]span{t.sessionSpan(session, end)}, t.spans...)
+	data, err := json.MarshalIndent(t.request(session, spans), "", "  ")
+	if err != nil {
+		report(t.program, err.Error())
+		return
+	}
+
+	tmp := t.target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		report(t.program, err.Error())
+		return
+	}
+	if err := os.Rename(tmp, t.target); err != nil {
+		report(t.program, err.Error())
+	}
+}
+
+// post sends spans to the OTLP endpoint in the background. Callers must hold t.mu.
+func (t *Tracer) post(spans []span, session *orchestrator.Session) {
+	data, err := json.Marshal(t.request(session, spans))
+	if err != nil {
+		report(t.program, err.Error())
+		return
+	}
+
+	program := t.program
+	t.pending.Add(1)
+	go func() {
+		defer t.pending.Done()
+		if err := t.send(data); err != nil {
+			report(program, fmt.Sprintf("exporting to %s: %v", t.target, err))
+		}
+	}()
+}
+
+// send performs a single export request
+func (t *Tracer) send(data []byte) error {
+	req, err := http.NewRequest("POST", t.target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range otlpHeaders() {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// otlpHeaders parses OTEL_EXPORTER_OTLP_HEADERS (key1=value1,key2=value2)
+func otlpHeaders() map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if v, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = v
+		}
+		headers[strings.TrimSpace(key)] = value
+	}
+	return headers
+}
+
+func newSpanID() string {
+	return randomHex(8)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// report shows an export problem in the output pane
+func report(p *tea.Program, msg string) {
+	if p != nil {
+		p.Send(orchestrator.OutputMsg{Content: "[trace] " + msg, Raw: true})
+	}
+}
@@ -0,0 +1,370 @@
+package vterm
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parser states
+const (
+	stateGround  = iota
+	stateEscape  // After ESC
+	stateCharset // After ESC ( and friends, which take one more byte
+	stateCSI     // After ESC [
+	stateOSC     // After ESC ], up to BEL or ST
+	stateOSCEsc  // ESC inside an OSC string, expecting \
+	stateString  // DCS, SOS, PM or APC strings, which are skipped
+	stateStrEsc  // ESC inside a skipped string
+)
+
+// parser holds an escape sequence or UTF-8 rune split across writes
+type parser struct {
+	state   int
+	params  []byte // CSI parameter and intermediate bytes
+	osc     []byte
+	partial []byte // Incomplete UTF-8 rune from the end of the last write
+}
+
+// Write interprets p, which may end partway through a rune or an escape
+// sequence; the rest is expected in the next write. It never fails.
+func (t *Terminal) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(t.parser.partial) > 0 {
+		p = append(t.parser.partial, p...)
+		t.parser.partial = nil
+	}
+
+	for len(p) > 0 {
+		b := p[0]
+		if t.parser.state != stateGround || b < 0x20 || b == 0x7f {
+			t.feed(b)
+			p = p[1:]
+			continue
+		}
+
+		r, size := utf8.DecodeRune(p)
+		if r == utf8.RuneError && size <= 1 && !utf8.FullRune(p) {
+			t.parser.partial = append([]byte(nil), p...)
+			break
+		}
+		t.put(r)
+		p = p[size:]
+	}
+	return n, nil
+}
+
+// feed handles one byte outside ordinary text
+func (t *Terminal) feed(b byte) {
+	ps := &t.parser
+	switch ps.state {
+	case stateGround:
+		t.control(b)
+
+	case stateEscape:
+		ps.state = stateGround
+		t.escape(b)
+
+	case stateCharset:
+		ps.state = stateGround
+
+	case stateCSI:
+		switch {
+		case b == 0x1b:
+			ps.state = stateEscape
+		case b < 0x20:
+			t.control(b)
+		case b < 0x40:
+			ps.params = append(ps.params, b)
+		default:
+			ps.state = stateGround
+			t.csi(string(ps.params), b)
+		}
+
+	case stateOSC:
+		switch b {
+		case 0x07:
+			ps.state = stateGround
+			t.oscEnd()
+		case 0x1b:
+			ps.state = stateOSCEsc
+		default:
+			ps.osc = append(ps.osc, b)
+		}
+
+	case stateOSCEsc:
+		ps.state = stateGround
+		t.oscEnd()
+		if b != '\\' {
+			t.feed(b)
+		}
+
+	case stateString:
+		switch b {
+		case 0x07:
+			ps.state = stateGround
+		case 0x1b:
+			ps.state = stateStrEsc
+		}
+
+	case stateStrEsc:
+		ps.state = stateGround
+		if b != '\\' {
+			t.feed(b)
+		}
+	}
+}
+
+// control handles a C0 control character
+func (t *Terminal) control(b byte) {
+	switch b {
+	case 0x1b:
+		t.parser.state = stateEscape
+	case '\r':
+		t.x = 0
+		t.wrapNext = false
+	case '\n', '\v', '\f':
+		t.lineFeed()
+		t.wrapNext = false
+	case '\b':
+		if t.x > 0 {
+			t.x--
+		}
+		t.wrapNext = false
+	case '\t':
+		t.x = min((t.x/8+1)*8, t.cols-1)
+		t.wrapNext = false
+	}
+}
+
+// escape handles the byte after ESC
+func (t *Terminal) escape(b byte) {
+	ps := &t.parser
+	switch b {
+	case '[':
+		ps.state = stateCSI
+		ps.params = ps.params[:0]
+	case ']':
+		ps.state = stateOSC
+		ps.osc = ps.osc[:0]
+	case 'P', 'X', '^', '_':
+		ps.state = stateString
+	case '(', ')', '*', '+', '#', '%':
+		ps.state = stateCharset
+	case '7':
+		t.saved = cursor{t.x, t.y, t.attr}
+	case '8':
+		t.moveTo(t.saved.x, t.saved.y)
+		t.attr = t.saved.attr
+	case 'D':
+		t.lineFeed()
+	case 'E':
+		t.x = 0
+		t.lineFeed()
+	case 'M':
+		t.reverseIndex()
+	case 'c':
+		t.reset()
+	}
+}
+
+// oscEnd handles a complete OSC string; only the window title is kept
+func (t *Terminal) oscEnd() {
+	cmd, text, ok := strings.Cut(string(t.parser.osc), ";")
+	if ok && (cmd == "0" || cmd == "2") {
+		t.title = text
+	}
+}
+
+// csi handles a control sequence: ESC [ params final
+func (t *Terminal) csi(params string, final byte) {
+	private := strings.HasPrefix(params, "?")
+	if strings.IndexFunc(params, func(r rune) bool { return r < '0' || r > '?' || strings.ContainsRune("<=>", r) }) >= 0 {
+		return // Intermediate bytes and other private forms aren't supported
+	}
+	args := parseParams(strings.TrimPrefix(params, "?"))
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	if private {
+		switch final {
+		case 'h':
+			t.setModes(args, true)
+		case 'l':
+			t.setModes(args, false)
+		}
+		return
+	}
+
+	switch final {
+	case 'A':
+		t.moveTo(t.x, t.y-arg(0, 1))
+	case 'B', 'e':
+		t.moveTo(t.x, t.y+arg(0, 1))
+	case 'C', 'a':
+		t.moveTo(t.x+arg(0, 1), t.y)
+	case 'D':
+		t.moveTo(t.x-arg(0, 1), t.y)
+	case 'E':
+		t.moveTo(0, t.y+arg(0, 1))
+	case 'F':
+		t.moveTo(0, t.y-arg(0, 1))
+	case 'G', '`':
+		t.moveTo(arg(0, 1)-1, t.y)
+	case 'H', 'f':
+		t.moveTo(arg(1, 1)-1, arg(0, 1)-1)
+	case 'd':
+		t.moveTo(t.x, arg(0, 1)-1)
+	case 'J':
+		t.eraseDisplay(arg(0, 0))
+	case 'K':
+		t.eraseLine(arg(0, 0))
+	case 'X':
+		t.erase(t.y, t.x, t.x+arg(0, 1))
+	case '@':
+		t.insertChars(arg(0, 1))
+	case 'P':
+		t.deleteChars(arg(0, 1))
+	case 'L':
+		if t.y >= t.top && t.y <= t.bottom {
+			t.scrollRegion(t.y, -arg(0, 1))
+			t.x = 0
+		}
+	case 'M':
+		if t.y >= t.top && t.y <= t.bottom {
+			t.scrollRegion(t.y, arg(0, 1))
+			t.x = 0
+		}
+	case 'S':
+		t.scrollUp(arg(0, 1))
+	case 'T':
+		t.scrollDown(arg(0, 1))
+	case 'r':
+		top, bottom := arg(0, 1)-1, arg(1, t.rows)-1
+		if top < bottom && bottom < t.rows {
+			t.top, t.bottom = top, bottom
+			t.moveTo(0, 0)
+		}
+	case 's':
+		t.saved = cursor{t.x, t.y, t.attr}
+	case 'u':
+		t.moveTo(t.saved.x, t.saved.y)
+		t.attr = t.saved.attr
+	case 'm':
+		t.sgr(args)
+	case 'n':
+		switch arg(0, 0) {
+		case 5:
+			t.replies = append(t.replies, "\x1b[0n"...)
+		case 6:
+			t.replies = append(t.replies, "\x1b["+strconv.Itoa(t.y+1)+";"+strconv.Itoa(t.x+1)+"R"...)
+		}
+	case 'c':
+		if arg(0, 0) == 0 {
+			t.replies = append(t.replies, "\x1b[?62;22c"...)
+		}
+	}
+}
+
+// setModes handles DEC private modes (CSI ? n h / l)
+func (t *Terminal) setModes(modes []int, on bool) {
+	for _, mode := range modes {
+		switch mode {
+		case 1:
+			t.appCursorKeys = on
+		case 7:
+			t.noAutowrap = !on
+		case 25:
+			t.hideCursor = !on
+		case 47, 1047:
+			t.setAltScreen(on, false)
+		case 1049:
+			t.setAltScreen(on, true)
+		case 2004:
+			t.bracketedPaste = on
+		}
+	}
+}
+
+// sgr applies Select Graphic Rendition parameters to the current attributes
+func (t *Terminal) sgr(args []int) {
+	if len(args) == 0 {
+		args = []int{0}
+	}
+	a := &t.attr
+	for i := 0; i < len(args); i++ {
+		switch n := args[i]; {
+		case n == 0:
+			*a = Attr{}
+		case n == 1:
+			a.Bold = true
+		case n == 2:
+			a.Faint = true
+		case n == 3:
+			a.Italic = true
+		case n == 4:
+			a.Underline = true
+		case n == 7:
+			a.Reverse = true
+		case n == 22:
+			a.Bold, a.Faint = false, false
+		case n == 23:
+			a.Italic = false
+		case n == 24:
+			a.Underline = false
+		case n == 27:
+			a.Reverse = false
+		case n >= 30 && n <= 37:
+			a.FG = Indexed(uint8(n - 30))
+		case n >= 90 && n <= 97:
+			a.FG = Indexed(uint8(n - 90 + 8))
+		case n == 39:
+			a.FG = DefaultColor
+		case n >= 40 && n <= 47:
+			a.BG = Indexed(uint8(n - 40))
+		case n >= 100 && n <= 107:
+			a.BG = Indexed(uint8(n - 100 + 8))
+		case n == 49:
+			a.BG = DefaultColor
+		case n == 38 || n == 48:
+			c, used := extendedColor(args[i+1:])
+			i += used
+			if n == 38 {
+				a.FG = c
+			} else {
+				a.BG = c
+			}
+		}
+	}
+}
+
+// extendedColor parses the rest of a 38 or 48 parameter: 5;n or 2;r;g;b.
+// It returns the colour and how many parameters it consumed.
+func extendedColor(args []int) (Color, int) {
+	if len(args) >= 2 && args[0] == 5 {
+		return Indexed(uint8(args[1])), 2
+	}
+	if len(args) >= 4 && args[0] == 2 {
+		return RGB(uint8(args[1]), uint8(args[2]), uint8(args[3])), 4
+	}
+	return DefaultColor, len(args)
+}
+
+// parseParams splits CSI parameters on ; (and the : used in some SGR
+// forms); missing values are 0
+func parseParams(s string) []int {
+	if s == "" {
+		return nil
+	}
+	fields := strings.Split(strings.ReplaceAll(s, ":", ";"), ";")
+	args := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, _ := strconv.Atoi(f)
+		args = append(args, n)
+	}
+	return args
+}
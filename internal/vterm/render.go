@@ -0,0 +1,98 @@
+package vterm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Render draws the screen as one line per row with ANSI SGR sequences for
+// the cell styles. Trailing blanks are trimmed. With cursor set, the cursor
+// cell is drawn in reverse video if the program hasn't hidden it.
+func (t *Terminal) Render(cursor bool) string {
+	lines := make([]string, t.rows)
+	for y := range t.rows {
+		cx := -1
+		if cursor && !t.hideCursor && y == t.y {
+			cx = t.x
+		}
+		lines[y] = t.renderLine(t.grid[y], cx)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderLine draws one row, reversing the cell at column cx (-1 for none)
+func (t *Terminal) renderLine(line []Cell, cx int) string {
+	end := len(line)
+	for end > 0 && end-1 != cx && line[end-1].Rune == 0 && !line[end-1].Wide && line[end-1].Attr == (Attr{}) {
+		end--
+	}
+
+	var sb strings.Builder
+	var cur Attr
+	for x := 0; x < end; x++ {
+		c := line[x]
+		if c.Wide {
+			continue
+		}
+		attr := c.Attr
+		if x == cx {
+			attr.Reverse = !attr.Reverse
+		}
+		if attr != cur {
+			sb.WriteString(sgrSequence(attr))
+			cur = attr
+		}
+		if c.Rune == 0 {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteRune(c.Rune)
+		}
+	}
+	if cur != (Attr{}) {
+		sb.WriteString("\x1b[0m")
+	}
+	return sb.String()
+}
+
+// sgrSequence returns the SGR sequence that resets to and sets a
+func sgrSequence(a Attr) string {
+	params := []string{"0"}
+	if a.Bold {
+		params = append(params, "1")
+	}
+	if a.Faint {
+		params = append(params, "2")
+	}
+	if a.Italic {
+		params = append(params, "3")
+	}
+	if a.Underline {
+		params = append(params, "4")
+	}
+	if a.Reverse {
+		params = append(params, "7")
+	}
+	params = append(params, colorParams(a.FG, 30, 90, 38)...)
+	params = append(params, colorParams(a.BG, 40, 100, 48)...)
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// colorParams returns the SGR parameters selecting c, given the bases for
+// the 8 normal colours, the 8 bright ones and extended colours
+func colorParams(c Color, normal, bright, extended int) []string {
+	switch {
+	case c == DefaultColor:
+		return nil
+	case c&rgbFlag != 0:
+		return []string{
+			strconv.Itoa(extended), "2",
+			strconv.Itoa(int(c>>16) & 0xff), strconv.Itoa(int(c>>8) & 0xff), strconv.Itoa(int(c) & 0xff),
+		}
+	case c <= 8:
+		return []string{strconv.Itoa(normal + int(c) - 1)}
+	case c <= 16:
+		return []string{strconv.Itoa(bright + int(c) - 9)}
+	default:
+		return []string{strconv.Itoa(extended), "5", strconv.Itoa(int(c) - 1)}
+	}
+}
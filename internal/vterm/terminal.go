@@ -0,0 +1,366 @@
+// Package vterm is a small virtual terminal: it interprets what a program
+// writes to its PTY (text, cursor movement, erasing, scrolling, SGR colours,
+// the alternate screen) into a grid of styled cells that the TUI can draw.
+// It covers the VT100/xterm subset interactive CLIs use, not every
+// sequence a full emulator knows.
+package vterm
+
+import (
+	"github.com/mattn/go-runewidth"
+)
+
+// Color is a cell colour: DefaultColor, one of the 256 indexed colours or a
+// 24-bit RGB value
+type Color uint32
+
+// DefaultColor is the terminal's default foreground or background
+const DefaultColor Color = 0
+
+// rgbFlag marks a Color holding an RGB value rather than an index
+const rgbFlag Color = 1 << 24
+
+// Indexed returns indexed colour i (0-15 are the ANSI colours)
+func Indexed(i uint8) Color {
+	return Color(i) + 1
+}
+
+// RGB returns a 24-bit colour
+func RGB(r, g, b uint8) Color {
+	return rgbFlag | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// Attr is the style of a cell
+type Attr struct {
+	FG, BG    Color
+	Bold      bool
+	Faint     bool
+	Italic    bool
+	Underline bool
+	Reverse   bool
+}
+
+// Cell is one position of the screen
+type Cell struct {
+	Rune rune // 0 for a blank cell
+	Attr Attr
+	Wide bool // Second half of a double-width rune; not drawn
+}
+
+// cursor is a cursor position with the attributes saved alongside it
+type cursor struct {
+	x, y int
+	attr Attr
+}
+
+// Terminal is the emulated screen. It is not safe for concurrent use.
+type Terminal struct {
+	cols, rows int
+	grid       [][]Cell // Screen being drawn on
+	mainGrid   [][]Cell // Main screen while the alternate one is shown
+	alt        bool
+
+	x, y     int
+	attr     Attr
+	wrapNext bool // The last column was written; wrap before the next rune
+	saved    cursor
+	altSaved cursor // Main screen cursor saved on entering the alternate screen
+
+	top, bottom int // Scroll region, inclusive
+
+	hideCursor     bool
+	noAutowrap     bool
+	appCursorKeys  bool
+	bracketedPaste bool
+	title          string
+
+	parser  parser
+	replies []byte // Responses to device queries, for the program's stdin
+}
+
+// New creates a blank terminal of the given size
+func New(cols, rows int) *Terminal {
+	t := &Terminal{}
+	t.Resize(cols, rows)
+	return t
+}
+
+// Size returns the terminal's columns and rows
+func (t *Terminal) Size() (cols, rows int) {
+	return t.cols, t.rows
+}
+
+// Title is the window title last set by the program (OSC 0 or 2)
+func (t *Terminal) Title() string {
+	return t.title
+}
+
+// AltScreen reports whether the alternate screen is shown
+func (t *Terminal) AltScreen() bool {
+	return t.alt
+}
+
+// AppCursorKeys reports whether the program asked for application cursor
+// keys (arrows sent as ESC O A rather than ESC [ A)
+func (t *Terminal) AppCursorKeys() bool {
+	return t.appCursorKeys
+}
+
+// BracketedPaste reports whether the program wants pastes bracketed
+func (t *Terminal) BracketedPaste() bool {
+	return t.bracketedPaste
+}
+
+// TakeReplies returns and clears the terminal's answers to device queries
+// (cursor position, device attributes), which belong on the program's input
+func (t *Terminal) TakeReplies() []byte {
+	r := t.replies
+	t.replies = nil
+	return r
+}
+
+// Cell returns the cell at column x, row y
+func (t *Terminal) Cell(x, y int) Cell {
+	return t.grid[y][x]
+}
+
+// Cursor returns the cursor position and whether it is shown
+func (t *Terminal) Cursor() (x, y int, visible bool) {
+	return t.x, t.y, !t.hideCursor
+}
+
+// Resize changes the screen size, keeping the content at the top left and
+// the cursor's line on screen
+func (t *Terminal) Resize(cols, rows int) {
+	cols, rows = max(cols, 1), max(rows, 1)
+	if cols == t.cols && rows == t.rows {
+		return
+	}
+
+	// Drop lines from the top if the cursor would fall off the bottom
+	shift := max(0, t.y-rows+1)
+	t.grid = resizeGrid(t.grid, cols, rows, shift)
+	if t.mainGrid != nil {
+		t.mainGrid = resizeGrid(t.mainGrid, cols, rows, 0)
+	}
+
+	t.cols, t.rows = cols, rows
+	t.y -= shift
+	t.x = min(t.x, cols-1)
+	t.y = min(t.y, rows-1)
+	t.wrapNext = false
+	t.top, t.bottom = 0, rows-1
+}
+
+// resizeGrid copies grid into a cols x rows grid, skipping shift lines
+func resizeGrid(grid [][]Cell, cols, rows, shift int) [][]Cell {
+	out := make([][]Cell, rows)
+	for y := range out {
+		out[y] = make([]Cell, cols)
+		if src := y + shift; src < len(grid) {
+			copy(out[y], grid[src])
+		}
+	}
+	return out
+}
+
+// reset returns the terminal to its initial state, keeping its size
+func (t *Terminal) reset() {
+	cols, rows := t.cols, t.rows
+	*t = Terminal{}
+	t.Resize(cols, rows)
+}
+
+// put writes r at the cursor and advances it, wrapping at the right margin
+func (t *Terminal) put(r rune) {
+	width := runewidth.RuneWidth(r)
+	if width == 0 {
+		return // Combining marks aren't tracked
+	}
+
+	if t.wrapNext {
+		t.wrapNext = false
+		if !t.noAutowrap {
+			t.x = 0
+			t.lineFeed()
+		}
+	}
+	if t.x+width > t.cols {
+		if t.noAutowrap {
+			t.x = t.cols - width
+		} else {
+			t.x = 0
+			t.lineFeed()
+		}
+	}
+	if t.x < 0 {
+		return // Wider than the screen
+	}
+
+	t.grid[t.y][t.x] = Cell{Rune: r, Attr: t.attr}
+	if width == 2 {
+		t.grid[t.y][t.x+1] = Cell{Attr: t.attr, Wide: true}
+	}
+	t.x += width
+	if t.x >= t.cols {
+		t.x = t.cols - 1
+		t.wrapNext = true
+	}
+}
+
+// blank is an empty cell in the current background colour
+func (t *Terminal) blank() Cell {
+	return Cell{Attr: Attr{BG: t.attr.BG}}
+}
+
+// lineFeed moves the cursor down, scrolling at the bottom of the region
+func (t *Terminal) lineFeed() {
+	switch {
+	case t.y == t.bottom:
+		t.scrollUp(1)
+	case t.y < t.rows-1:
+		t.y++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling at the top of the region
+func (t *Terminal) reverseIndex() {
+	switch {
+	case t.y == t.top:
+		t.scrollDown(1)
+	case t.y > 0:
+		t.y--
+	}
+}
+
+// scrollUp moves the lines of the scroll region up n, blanking the bottom
+func (t *Terminal) scrollUp(n int) {
+	t.scrollRegion(t.top, n)
+}
+
+// scrollDown moves the lines of the scroll region down n, blanking the top
+func (t *Terminal) scrollDown(n int) {
+	t.scrollRegion(t.top, -n)
+}
+
+// scrollRegion scrolls the lines from row from to the bottom of the scroll
+// region up by n (down if n is negative)
+func (t *Terminal) scrollRegion(from, n int) {
+	height := t.bottom - from + 1
+	if height <= 0 || n == 0 {
+		return
+	}
+	if n > height {
+		n = height
+	} else if n < -height {
+		n = -height
+	}
+
+	lines := t.grid[from : t.bottom+1]
+	if n > 0 {
+		copy(lines, lines[n:])
+		for i := height - n; i < height; i++ {
+			lines[i] = t.blankLine()
+		}
+	} else {
+		copy(lines[-n:], lines[:height+n])
+		for i := 0; i < -n; i++ {
+			lines[i] = t.blankLine()
+		}
+	}
+}
+
+// blankLine returns a new empty line
+func (t *Terminal) blankLine() []Cell {
+	line := make([]Cell, t.cols)
+	for i := range line {
+		line[i] = t.blank()
+	}
+	return line
+}
+
+// erase blanks the cells from (x0, y) to (x1, y), x1 exclusive
+func (t *Terminal) erase(y, x0, x1 int) {
+	x0, x1 = max(x0, 0), min(x1, t.cols)
+	for x := x0; x < x1; x++ {
+		t.grid[y][x] = t.blank()
+	}
+}
+
+// eraseDisplay implements ED: 0 below the cursor, 1 above it, 2 or 3 all
+func (t *Terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.erase(t.y, t.x, t.cols)
+		for y := t.y + 1; y < t.rows; y++ {
+			t.erase(y, 0, t.cols)
+		}
+	case 1:
+		for y := 0; y < t.y; y++ {
+			t.erase(y, 0, t.cols)
+		}
+		t.erase(t.y, 0, t.x+1)
+	case 2, 3:
+		for y := 0; y < t.rows; y++ {
+			t.erase(y, 0, t.cols)
+		}
+	}
+}
+
+// eraseLine implements EL: 0 right of the cursor, 1 left of it, 2 the line
+func (t *Terminal) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		t.erase(t.y, t.x, t.cols)
+	case 1:
+		t.erase(t.y, 0, t.x+1)
+	case 2:
+		t.erase(t.y, 0, t.cols)
+	}
+}
+
+// insertChars shifts the rest of the line right by n blanks
+func (t *Terminal) insertChars(n int) {
+	line := t.grid[t.y]
+	n = min(n, t.cols-t.x)
+	copy(line[t.x+n:], line[t.x:t.cols-n])
+	t.erase(t.y, t.x, t.x+n)
+}
+
+// deleteChars shifts the rest of the line left by n, blanking the end
+func (t *Terminal) deleteChars(n int) {
+	line := t.grid[t.y]
+	n = min(n, t.cols-t.x)
+	copy(line[t.x:], line[t.x+n:])
+	t.erase(t.y, t.cols-n, t.cols)
+}
+
+// setAltScreen switches to or from the alternate screen
+func (t *Terminal) setAltScreen(on, saveCursor bool) {
+	if on == t.alt {
+		return
+	}
+	if on {
+		if saveCursor {
+			t.altSaved = cursor{t.x, t.y, t.attr}
+		}
+		t.mainGrid = t.grid
+		t.grid = resizeGrid(nil, t.cols, t.rows, 0)
+	} else {
+		t.grid = t.mainGrid
+		t.mainGrid = nil
+		if saveCursor {
+			// The screen may have shrunk since the cursor was saved
+			t.moveTo(t.altSaved.x, t.altSaved.y)
+			t.attr = t.altSaved.attr
+		}
+	}
+	t.alt = on
+	t.wrapNext = false
+}
+
+// moveTo puts the cursor at column x, row y, clamped to the screen
+func (t *Terminal) moveTo(x, y int) {
+	t.x = min(max(x, 0), t.cols-1)
+	t.y = min(max(y, 0), t.rows-1)
+	t.wrapNext = false
+}
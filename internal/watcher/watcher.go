@@ -1,78 +1,274 @@
 package watcher
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
-// Watch watches the specified files and sends changes to the channel
-func Watch(files []string, changes chan<- string) error {
-	watcher, err := fsnotify.NewWatcher()
+// DefaultDebounce is how long the files must stay unchanged before a burst of
+// changes is reported. Editors and git touch several files per save or commit.
+const DefaultDebounce = 150 * time.Millisecond
+
+// maxDelay bounds how long a burst that never settles is held back
+const maxDelay = 2 * time.Second
+
+// Target is a named set of paths to watch. Each pattern is a file, a glob
+// ("prd/*.md") or a directory, written with a trailing slash (".ralph-logs/"),
+// whose whole tree is watched. Paths need not exist yet.
+type Target struct {
+	Name     string
+	Patterns []string
+}
+
+// Event reports that files of a target changed (written, created, removed or
+// renamed), or that watching failed
+type Event struct {
+	Target string   // Target.Name
+	Paths  []string // Changed paths, sorted
+	Err    error
+}
+
+// Watcher watches targets and reports debounced changes on Events
+type Watcher struct {
+	targets  []Target
+	debounce time.Duration
+	fs       *fsnotify.Watcher
+	events   chan Event
+	watched  map[string]bool // Directories added to fs
+	failed   map[string]bool // Directories that couldn't be added, reported once
+}
+
+// New starts watching targets until ctx is cancelled, when Events is closed
+func New(ctx context.Context, debounce time.Duration, targets ...Target) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer watcher.Close()
-
-	// Add files to watch
-	for _, file := range files {
-		// Watch the directory containing the file
-		dir := filepath.Dir(file)
-		if dir == "" {
-			dir = "."
-		}
 
-		err = watcher.Add(dir)
-		if err != nil {
-			log.Printf("Warning: Could not watch %s: %v", file, err)
-		}
+	w := &Watcher{
+		targets:  targets,
+		debounce: debounce,
+		fs:       fs,
+		events:   make(chan Event, len(targets)+1),
+		watched:  make(map[string]bool),
+		failed:   make(map[string]bool),
 	}
+	errs := w.addDirs()
+
+	go w.run(ctx, errs)
+	return w, nil
+}
 
-	// Create a set of files we're interested in
-	watchedFiles := make(map[string]bool)
-	for _, f := range files {
-		abs, _ := filepath.Abs(f)
-		watchedFiles[abs] = true
-		// Also add the base name for relative matching
-		watchedFiles[filepath.Base(f)] = true
+// Events delivers one event per target with changes once they settle
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// run collects fsnotify events into batches until ctx is done, first
+// reporting errs from watching the initial directories
+func (w *Watcher) run(ctx context.Context, errs []error) {
+	defer close(w.events)
+	defer w.fs.Close()
+
+	for _, err := range errs {
+		if !w.send(ctx, Event{Err: err}) {
+			return
+		}
 	}
 
-	// Watch for events
+	pending := make(map[string]map[string]bool) // Target name -> changed paths
+	timer := time.NewTimer(0)
+	<-timer.C
+	var flush <-chan time.Time
+	var burstStart time.Time // First change of the pending burst
+
 	for {
 		select {
-		case event, ok := <-watcher.Events:
+		case <-ctx.Done():
+			return
+
+		case ev, ok := <-w.fs.Events:
 			if !ok {
-				return nil
+				return
 			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			path := filepath.Clean(ev.Name)
 
-			// Check if this file is one we're watching
-			basename := filepath.Base(event.Name)
-			absname, _ := filepath.Abs(event.Name)
+			// New directories may hold or lead to watched paths; removed
+			// ones are dropped by fsnotify and re-added if they come back
+			if ev.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(path); err == nil && info.IsDir() {
+					for _, err := range w.addDirs() {
+						if !w.send(ctx, Event{Err: err}) {
+							return
+						}
+					}
+				}
+			}
+			if ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0 && w.watched[path] {
+				delete(w.watched, path)
+			}
 
-			if watchedFiles[basename] || watchedFiles[absname] {
-				if event.Op&fsnotify.Write == fsnotify.Write ||
-					event.Op&fsnotify.Create == fsnotify.Create {
-					changes <- basename
+			for _, t := range w.targets {
+				if !matches(t, path) {
+					continue
 				}
+				if pending[t.Name] == nil {
+					pending[t.Name] = make(map[string]bool)
+				}
+				pending[t.Name][path] = true
+			}
+			// Each change restarts the wait, up to maxDelay after the first
+			if len(pending) > 0 {
+				now := time.Now()
+				if flush == nil {
+					burstStart = now
+				} else if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(max(0, min(w.debounce, burstStart.Add(maxDelay).Sub(now))))
+				flush = timer.C
 			}
 
-		case err, ok := <-watcher.Errors:
+		case err, ok := <-w.fs.Errors:
 			if !ok {
-				return nil
+				return
+			}
+			if !w.send(ctx, Event{Err: err}) {
+				return
+			}
+
+		case <-flush:
+			flush = nil
+			for _, t := range w.targets {
+				paths := pending[t.Name]
+				if len(paths) == 0 {
+					continue
+				}
+				ev := Event{Target: t.Name}
+				for p := range paths {
+					ev.Paths = append(ev.Paths, p)
+				}
+				sort.Strings(ev.Paths)
+				if !w.send(ctx, ev) {
+					return
+				}
 			}
-			log.Printf("Watcher error: %v", err)
+			pending = make(map[string]map[string]bool)
 		}
 	}
 }
 
-// WatchWithCallback watches files and calls the callback on changes
-func WatchWithCallback(files []string, callback func(string)) error {
-	changes := make(chan string)
-	go func() {
-		for file := range changes {
-			callback(file)
+// send delivers ev unless ctx is cancelled first
+func (w *Watcher) send(ctx context.Context, ev Event) bool {
+	select {
+	case w.events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// addDirs watches every directory the targets need: the directory holding
+// each file or glob, or the whole tree of a directory pattern. A directory
+// that doesn't exist yet is stood in for by its nearest existing parent, so
+// its creation is seen. Directories that can't be watched are returned as
+// errors, each only the first time.
+func (w *Watcher) addDirs() []error {
+	var errs []error
+	add := func(dir string) {
+		if err := w.add(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, t := range w.targets {
+		for _, pattern := range t.Patterns {
+			if isDirPattern(pattern) {
+				root := filepath.Clean(pattern)
+				if _, err := os.Stat(root); err != nil {
+					add(existingParent(root))
+					continue
+				}
+				filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+					if err == nil && d.IsDir() {
+						add(path)
+					}
+					return nil
+				})
+				continue
+			}
+
+			dir := filepath.Dir(filepath.Clean(pattern))
+			dirs, _ := filepath.Glob(dir)
+			if len(dirs) == 0 {
+				add(existingParent(dir))
+			}
+			for _, d := range dirs {
+				add(d)
+			}
+		}
+	}
+	return errs
+}
+
+// add watches dir once, returning an error the first time it can't be
+func (w *Watcher) add(dir string) error {
+	if w.watched[dir] {
+		return nil
+	}
+	if err := w.fs.Add(dir); err != nil {
+		if w.failed[dir] {
+			return nil
 		}
-	}()
-	return Watch(files, changes)
+		w.failed[dir] = true
+		return fmt.Errorf("watching %s: %w", dir, err)
+	}
+	w.watched[dir] = true
+	delete(w.failed, dir)
+	return nil
+}
+
+// matches reports whether path belongs to target t
+func matches(t Target, path string) bool {
+	for _, pattern := range t.Patterns {
+		clean := filepath.Clean(pattern)
+		if isDirPattern(pattern) {
+			if path == clean || strings.HasPrefix(path, clean+string(filepath.Separator)) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(clean, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isDirPattern reports whether pattern names a directory tree
+func isDirPattern(pattern string) bool {
+	return strings.HasSuffix(pattern, "/") || strings.HasSuffix(pattern, string(filepath.Separator))
+}
+
+// existingParent returns the nearest directory at or above dir that exists
+func existingParent(dir string) string {
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
 }